@@ -23,11 +23,21 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
+	"github.com/erikgeiser/promptkit/selection"
+	"github.com/mattn/go-isatty"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"github.com/chapterjason/j3n/mod/action"
 	"github.com/chapterjason/j3n/modx/viperx"
@@ -35,44 +45,440 @@ import (
 
 // actionCmd represents the action command
 var actionCmd = &cobra.Command{
-	Use:   "action [name]",
+	Use:   "action [name] [parameters...]",
 	Short: "Run an action",
-	Args:  cobra.ExactArgs(1),
+	Long:  "Run an action. Without a name, the action is selected interactively when running in a terminal. Actions can not be named like the subcommands list, graph and cache.",
+	Args:  cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		as := viper.AllSettings()
+		l, err := loadActions()
 
-		if as == nil {
-			return fmt.Errorf("no actions defined")
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			if !isatty.IsTerminal(os.Stdin.Fd()) || !isatty.IsTerminal(os.Stdout.Fd()) {
+				return errors.New("requires an action name when not running in a terminal")
+			}
+
+			name, err := askForAction(l)
+
+			if err != nil {
+				return errors.Wrap(err, "failed to ask for action")
+			}
+
+			args = []string{name}
 		}
 
-		var l action.List
+		options := action.Options{}
 
-		err := viperx.Transcode(as, &l)
+		options.Jobs, err = cmd.Flags().GetInt("jobs")
 
 		if err != nil {
 			return err
 		}
 
-		ep := action.NewExecuter(&l)
+		policy, err := cmd.Flags().GetString("policy")
 
-		ers, err := ep.Execute(args[0])
+		if err != nil {
+			return err
+		}
+
+		if policy != "" {
+			options.Policy, err = action.ParsePolicy(policy)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		noCache, err := cmd.Flags().GetBool("no-cache")
 
 		if err != nil {
 			return err
 		}
 
-		if len(ers) > 0 {
-			for actionName, er := range ers {
-				for stepName, err := range er {
-					log.Errorf("action(%s): step(%s): %s", actionName, stepName, err)
-				}
+		if !noCache {
+			options.Cache = action.NewCache(action.DefaultCacheDirectory)
+		}
+
+		output, err := cmd.Flags().GetString("output")
+
+		if err != nil {
+			return err
+		}
+
+		if output != "" {
+			options.OutputMode, err = action.ParseOutputMode(output)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		noColor, err := cmd.Flags().GetBool("no-color")
+
+		if err != nil {
+			return err
+		}
+
+		color := !noColor && isatty.IsTerminal(os.Stdout.Fd())
+		options.Printer = action.NewPrinter(cmd.OutOrStdout(), color)
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// the configuration is validated before the expansion, so problems
+		// are reported at the paths of the configuration.
+		if err := l.Validate(); err != nil {
+			return err
+		}
+
+		list, err := l.Expand()
+
+		if err != nil {
+			return err
+		}
+
+		root, err := list.GetAction(args[0])
+
+		if err != nil {
+			return errors.Wrapf(err, "action %s", args[0])
+		}
+
+		params, err := cmd.Flags().GetStringArray("param")
+
+		if err != nil {
+			return err
+		}
+
+		named, err := action.ParseNamedParameters(params)
+
+		if err != nil {
+			return err
+		}
+
+		values, err := root.BindParameters(args[1:], named)
+
+		if err != nil {
+			return errors.Wrapf(err, "action %s", args[0])
+		}
+
+		options.Parameters = map[string]map[string]any{args[0]: values}
+
+		filter, filtered, err := getStepFilter(cmd)
+
+		if err != nil {
+			return err
+		}
+
+		selection, err := list.SelectSteps(args[0], filter)
+
+		if err != nil {
+			return err
+		}
+
+		ep := action.NewExecuter(list, options)
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+
+		if err != nil {
+			return err
+		}
+
+		asJson, err := cmd.Flags().GetBool("json")
+
+		if err != nil {
+			return err
+		}
+
+		if asJson && !dryRun {
+			return errors.New("--json requires --dry-run")
+		}
+
+		if dryRun {
+			plan, err := ep.PlanSelection(args[0], selection)
+
+			if err != nil {
+				return err
 			}
+
+			if asJson {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+
+				return encoder.Encode(plan)
+			}
+
+			return printPlan(cmd.OutOrStdout(), plan)
+		}
+
+		watch, err := cmd.Flags().GetBool("watch")
+
+		if err != nil {
+			return err
+		}
+
+		if watch && filtered {
+			return errors.New("--watch can not be used with --only, --from or --skip")
+		}
+
+		if watch {
+			w, err := action.NewWatcher(ep, args[0])
+
+			if err != nil {
+				return err
+			}
+
+			return w.Run(
+				ctx, func(report *action.Report) {
+					logReport(report)
+
+					if err := printReport(cmd.OutOrStdout(), report); err != nil {
+						log.Errorf("%s", err)
+					}
+				},
+			)
+		}
+
+		report, err := ep.ExecuteSelection(ctx, args[0], selection)
+
+		if err != nil {
+			return err
+		}
+
+		logReport(report)
+
+		err = printReport(cmd.OutOrStdout(), report)
+
+		if err != nil {
+			return err
+		}
+
+		if !report.Succeeded() {
+			return errors.Errorf(
+				"action %s: %d failed, %d skipped, %d cancelled",
+				args[0],
+				report.Count(action.StatusFailed),
+				report.Count(action.StatusSkipped),
+				report.Count(action.StatusCancelled),
+			)
 		}
 
 		return nil
 	},
 }
 
+// getStepFilter returns the filter of the steps of the action given by the
+// flags and whether any steps are filtered. The dependencies of the selected
+// steps are only included with --only or --from if --with-deps is given, and
+// the dependencies of the action are always included unless --no-deps is
+// given.
+func getStepFilter(cmd *cobra.Command) (action.StepFilter, bool, error) {
+	var filter action.StepFilter
+	var err error
+
+	if filter.Only, err = cmd.Flags().GetStringSlice("only"); err != nil {
+		return filter, false, err
+	}
+
+	if filter.Skip, err = cmd.Flags().GetStringSlice("skip"); err != nil {
+		return filter, false, err
+	}
+
+	if filter.From, err = cmd.Flags().GetString("from"); err != nil {
+		return filter, false, err
+	}
+
+	withDeps, err := cmd.Flags().GetBool("with-deps")
+
+	if err != nil {
+		return filter, false, err
+	}
+
+	noDeps, err := cmd.Flags().GetBool("no-deps")
+
+	if err != nil {
+		return filter, false, err
+	}
+
+	if withDeps && noDeps {
+		return filter, false, errors.New("--with-deps can not be used with --no-deps")
+	}
+
+	filter.Dependencies = !noDeps && (withDeps || (len(filter.Only) == 0 && filter.From == ""))
+
+	filtered := len(filter.Only) > 0 || len(filter.Skip) > 0 || filter.From != "" || noDeps
+
+	return filter, filtered, nil
+}
+
+func loadActions() (*action.List, error) {
+	as, err := loadConfig()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if as == nil {
+		return nil, fmt.Errorf("no actions defined")
+	}
+
+	var l action.List
+
+	if err := viperx.Transcode(as, &l); err != nil {
+		return nil, err
+	}
+
+	if len(l.Actions) == 0 {
+		return nil, fmt.Errorf("no actions defined")
+	}
+
+	// "j3n action list" runs the subcommand, so an action named like one
+	// could never be run.
+	if c, _, err := rootCmd.Find([]string{"action"}); err == nil {
+		for _, subcommand := range c.Commands() {
+			if _, ok := l.Actions[subcommand.Name()]; ok {
+				return nil, fmt.Errorf("action name %q is reserved for the command \"j3n action %s\", rename the action", subcommand.Name(), subcommand.Name())
+			}
+		}
+	}
+
+	return l.ResolveTemplates()
+}
+
+func askForAction(l *action.List) (string, error) {
+	choices := []*selection.Choice{}
+
+	for _, name := range l.GetNames() {
+		label := name
+
+		if description := l.Actions[name].Description; description != "" {
+			label += " - " + description
+		}
+
+		choices = append(choices, &selection.Choice{String: label, Value: name})
+	}
+
+	sel := selection.New("Which action do you want to run?", choices)
+	item, err := sel.RunPrompt()
+
+	if err != nil {
+		return "", err
+	}
+
+	return item.Value.(string), nil
+}
+
+func logReport(report *action.Report) {
+	for _, result := range report.Results() {
+		if result.Status == action.StatusFailed {
+			log.Errorf("action(%s): step(%s): %s", result.Action, result.Step, result.Error)
+		}
+	}
+}
+
+func printReport(out io.Writer, report *action.Report) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "ACTION\tSTEP\tSTATUS\tDURATION")
+
+	for _, result := range report.Results() {
+		status := string(result.Status)
+
+		if result.Status == action.StatusSkipped && result.Error != nil {
+			status += " (" + result.Error.Error() + ")"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Action, result.Step, status, result.Duration.Round(time.Millisecond))
+	}
+
+	return w.Flush()
+}
+
+// printPlan prints the layers of the actions and their steps, each step
+// with the command it would run or its params.
+func printPlan(out io.Writer, plan *action.Plan) error {
+	condition := func(ifCondition string, unlessCondition string) string {
+		switch {
+		case ifCondition != "":
+			return " if " + ifCondition
+		case unlessCondition != "":
+			return " unless " + unlessCondition
+		}
+
+		return ""
+	}
+
+	for i, actions := range plan.Layers {
+		for _, a := range actions {
+			fmt.Fprintf(out, "[%d] %s%s\n", i+1, a.Name, condition(a.If, a.Unless))
+
+			for j, steps := range a.Layers {
+				for _, step := range steps {
+					fmt.Fprintf(out, "    [%d] %s (%s)%s\n", j+1, step.Name, step.Type, condition(step.If, step.Unless))
+
+					if step.Input != "" {
+						fmt.Fprintf(out, "        input: %s\n", step.Input)
+					}
+
+					switch {
+					case step.Error != "":
+						fmt.Fprintf(out, "        error: %s\n", step.Error)
+					case len(step.Command) > 0:
+						fmt.Fprintf(out, "        $ %s\n", quoteCommand(step.Command))
+						fmt.Fprintf(out, "        directory: %s\n", step.Directory)
+
+						if len(step.Env) > 0 {
+							fmt.Fprintf(out, "        env: %s\n", quoteCommand(step.Env))
+						}
+					case len(step.Params) > 0:
+						b, err := json.Marshal(step.Params)
+
+						if err != nil {
+							return err
+						}
+
+						fmt.Fprintf(out, "        params: %s\n", b)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// quoteCommand returns the arguments as a shell command line.
+func quoteCommand(args []string) string {
+	quoted := []string{}
+
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$`|&;<>()*?[]#~{}") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+
+		quoted = append(quoted, arg)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
 func init() {
 	rootCmd.AddCommand(actionCmd)
+
+	actionCmd.Flags().IntP("jobs", "j", 0, "maximum number of steps running at the same time (default is the number of CPUs)")
+	actionCmd.Flags().String("policy", "", "policy on failed steps: fail_fast, finish_layer or keep_going (default is the policy of the action or finish_layer)")
+	actionCmd.Flags().Bool("no-cache", false, "run all steps even if their inputs did not change")
+	actionCmd.Flags().String("output", "", "how the output of steps is printed: stream, grouped or quiet (default is the output mode of the step or grouped)")
+	actionCmd.Flags().BoolP("watch", "w", false, "rerun the affected steps whenever their inputs or the watched paths change")
+	actionCmd.Flags().Bool("no-color", false, "do not color the prefix of printed step output")
+	actionCmd.Flags().StringSlice("only", []string{}, "run only the given steps of the action, like --only test,lint")
+	actionCmd.Flags().StringSlice("skip", []string{}, "do not run the given steps of the action, like --skip fmt,tidy")
+	actionCmd.Flags().String("from", "", "run the given step of the action and all steps depending on it")
+	actionCmd.Flags().Bool("with-deps", false, "also run the dependencies of the steps given by --only or --from")
+	actionCmd.Flags().Bool("no-deps", false, "do not run the dependencies of the steps, not even the actions the action depends on")
+	actionCmd.Flags().Bool("dry-run", false, "print the plan of the execution without running any step")
+	actionCmd.Flags().Bool("json", false, "print the plan of --dry-run as json")
+	actionCmd.Flags().StringArrayP("param", "p", []string{}, "set a parameter of the action like env=staging, parameters can also be given as arguments in their declared order")
 }
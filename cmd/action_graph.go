@@ -0,0 +1,62 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/chapterjason/j3n/mod/action"
+)
+
+var actionGraphCmd = &cobra.Command{
+	Use:   "graph [name...]",
+	Short: "Render the dependency graphs of the actions and their steps",
+	Long:  "Render the dependency graph of the actions and the graph of the steps of every action, with their execution layers. Cycles are highlighted. With names, only these actions and their dependencies are rendered.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		l, err := loadActions()
+
+		if err != nil {
+			return err
+		}
+
+		f, err := cmd.Flags().GetString("format")
+
+		if err != nil {
+			return err
+		}
+
+		format, err := action.ParseGraphFormat(f)
+
+		if err != nil {
+			return err
+		}
+
+		return action.RenderGraph(cmd.OutOrStdout(), l, args, format)
+	},
+}
+
+func init() {
+	actionCmd.AddCommand(actionGraphCmd)
+
+	actionGraphCmd.Flags().StringP("format", "f", string(action.GraphASCII), "format of the graph: dot, mermaid or ascii")
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/chapterjason/j3n/mod/action"
+)
+
+type actionListEntry struct {
+	Name         string              `json:"name"`
+	Description  string              `json:"description,omitempty"`
+	Dependencies []string            `json:"dependencies"`
+	Parameters   []*action.Parameter `json:"parameters"`
+	Steps        []stepListEntry     `json:"steps"`
+}
+
+type stepListEntry struct {
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	Description  string   `json:"description,omitempty"`
+	Dependencies []string `json:"dependencies"`
+}
+
+var actionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the actions with their dependencies and steps",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		l, err := loadActions()
+
+		if err != nil {
+			return err
+		}
+
+		entries := getActionListEntries(l)
+
+		asJson, err := cmd.Flags().GetBool("json")
+
+		if err != nil {
+			return err
+		}
+
+		if asJson {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+
+			return encoder.Encode(entries)
+		}
+
+		printActionList(cmd.OutOrStdout(), entries)
+
+		return nil
+	},
+}
+
+func getActionListEntries(l *action.List) []actionListEntry {
+	entries := []actionListEntry{}
+
+	for _, name := range l.GetNames() {
+		a := l.Actions[name]
+
+		entry := actionListEntry{
+			Name:         name,
+			Description:  a.Description,
+			Dependencies: append([]string{}, a.Dependencies...),
+			Parameters:   append([]*action.Parameter{}, a.Parameters...),
+			Steps:        []stepListEntry{},
+		}
+
+		stepNames := []string{}
+
+		for stepName := range a.Steps {
+			stepNames = append(stepNames, stepName)
+		}
+
+		sort.Strings(stepNames)
+
+		for _, stepName := range stepNames {
+			step := a.Steps[stepName]
+
+			entry.Steps = append(
+				entry.Steps, stepListEntry{
+					Name:         stepName,
+					Type:         step.Type,
+					Description:  step.Description,
+					Dependencies: append([]string{}, step.Dependencies...),
+				},
+			)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func printActionList(out io.Writer, entries []actionListEntry) {
+	for i, entry := range entries {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+
+		fmt.Fprintln(out, withDescription(entry.Name, entry.Description))
+
+		if len(entry.Dependencies) > 0 {
+			fmt.Fprintf(out, "  dependencies: %s\n", strings.Join(entry.Dependencies, ", "))
+		}
+
+		if len(entry.Parameters) > 0 {
+			parameters := []string{}
+
+			for _, parameter := range entry.Parameters {
+				switch {
+				case parameter.Required:
+					parameters = append(parameters, parameter.Name+" (required)")
+				case parameter.Default != nil:
+					parameters = append(parameters, parameter.Name+"="+action.FormatOutput(parameter.Default))
+				default:
+					parameters = append(parameters, parameter.Name)
+				}
+			}
+
+			fmt.Fprintf(out, "  parameters: %s\n", strings.Join(parameters, ", "))
+		}
+
+		fmt.Fprintln(out, "  steps:")
+
+		for _, step := range entry.Steps {
+			line := withDescription(step.Name, step.Description)
+
+			if len(step.Dependencies) > 0 {
+				line += fmt.Sprintf(" (after %s)", strings.Join(step.Dependencies, ", "))
+			}
+
+			fmt.Fprintf(out, "    %s\n", line)
+		}
+	}
+}
+
+func withDescription(name string, description string) string {
+	if description == "" {
+		return name
+	}
+
+	return name + " - " + description
+}
+
+func init() {
+	actionCmd.AddCommand(actionListCmd)
+
+	actionListCmd.Flags().Bool("json", false, "print the actions as json")
+}
@@ -23,6 +23,9 @@
 package action
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/pkg/errors"
 
 	"github.com/chapterjason/j3n/mod/topology"
@@ -33,8 +36,23 @@ var (
 )
 
 type Action struct {
+	Description  string           `json:"description,omitempty" yaml:"description,omitempty"`
+	Extends      string           `json:"extends,omitempty" yaml:"extends,omitempty"`
+	Parameters   []*Parameter     `json:"parameters,omitempty" yaml:"parameters,omitempty"`
 	Dependencies []string         `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
 	Steps        map[string]*Step `json:"steps" yaml:"steps"`
+	Timeout      Duration         `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Policy       Policy           `json:"policy,omitempty" yaml:"policy,omitempty"`
+	Watch        *Watch           `json:"watch,omitempty" yaml:"watch,omitempty"`
+	MaxParallel  int              `json:"max_parallel,omitempty" yaml:"max_parallel,omitempty"`
+	If           string           `json:"if,omitempty" yaml:"if,omitempty"`
+	Unless       string           `json:"unless,omitempty" yaml:"unless,omitempty"`
+	// OnSkippedDependency decides what happens to the steps whose dependency
+	// has been skipped because its condition was not met.
+	OnSkippedDependency SkipRule `json:"on_skipped_dependency,omitempty" yaml:"on_skipped_dependency,omitempty"`
+	// Outputs publishes references like "compile.output" under a name, so
+	// other actions can reference them as "action.name".
+	Outputs map[string]string `json:"outputs,omitempty" yaml:"outputs,omitempty"`
 }
 
 func (a *Action) GetStep(step string) (*Step, error) {
@@ -47,6 +65,19 @@ func (a *Action) GetStep(step string) (*Step, error) {
 	return s, nil
 }
 
+// GetStepNames returns the names of all steps in alphabetical order.
+func (a *Action) GetStepNames() []string {
+	names := []string{}
+
+	for name := range a.Steps {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
 func (a *Action) GetGraph() *topology.DependencyGraph {
 	graph := topology.NewDependencyGraph()
 
@@ -55,6 +86,12 @@ func (a *Action) GetGraph() *topology.DependencyGraph {
 
 		for _, dep := range step.Dependencies {
 			graph.AddEdge(stepName, dep)
+
+			// steps of other actions are run before the action, so they are
+			// only added as nodes without dependencies.
+			if strings.Contains(dep, ".") {
+				graph.AddNode(dep)
+			}
 		}
 	}
 
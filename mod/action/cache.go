@@ -0,0 +1,250 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/chapterjason/j3n/modx/filepathx"
+	"github.com/chapterjason/j3n/modx/slicex"
+)
+
+const (
+	DefaultCacheDirectory = ".j3n/cache"
+
+	// cacheVersion is part of every key and has to be increased whenever the
+	// format of the entries changes.
+	cacheVersion = 3
+)
+
+// Cache stores the results of steps which declare their input files, keyed
+// by the step definition and the contents of those files.
+type Cache struct {
+	directory string
+}
+
+type CacheEntry struct {
+	Outputs Outputs `json:"outputs,omitempty"`
+	// Types are the types of the outputs which are lost in json, like "int"
+	// for an exit code, so a cached output has the type of a fresh one.
+	Types map[string]string `json:"types,omitempty"`
+	Files map[string]string `json:"files,omitempty"`
+}
+
+func NewCache(directory string) *Cache {
+	return &Cache{
+		directory,
+	}
+}
+
+// Key returns the cache key of a step with its expanded params, or an empty
+// string if the step does not declare any inputs and can therefore not be
+// cached.
+func (c *Cache) Key(step *Step, params map[string]any, input any) (string, error) {
+	if len(step.Inputs) == 0 {
+		return "", nil
+	}
+
+	files, err := hashFiles(step.Inputs)
+
+	if err != nil {
+		return "", errors.Wrap(err, "failed to hash inputs")
+	}
+
+	b, err := json.Marshal(
+		map[string]any{
+			"version": cacheVersion,
+			"type":    step.Type,
+			"params":  params,
+			"input":   input,
+			"files":   files,
+		},
+	)
+
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode cache key")
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Get returns the entry for the given key if it exists and the declared
+// outputs of the step did not change since the entry has been written.
+func (c *Cache) Get(key string, step *Step) (*CacheEntry, bool, error) {
+	b, err := os.ReadFile(c.getPath(key))
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+
+		return nil, false, errors.Wrap(err, "failed to read cache entry")
+	}
+
+	var entry CacheEntry
+
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return nil, false, errors.Wrap(err, "failed to decode cache entry")
+	}
+
+	files, err := hashFiles(step.Outputs)
+
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to hash outputs")
+	}
+
+	if len(files) != len(entry.Files) {
+		return nil, false, nil
+	}
+
+	for file, hash := range entry.Files {
+		if files[file] != hash {
+			return nil, false, nil
+		}
+	}
+
+	restoreOutputTypes(entry.Outputs, entry.Types)
+
+	return &entry, true, nil
+}
+
+func (c *Cache) Put(key string, step *Step, outputs Outputs) error {
+	files, err := hashFiles(step.Outputs)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to hash outputs")
+	}
+
+	b, err := json.Marshal(
+		CacheEntry{
+			Outputs: outputs,
+			Types:   getOutputTypes(outputs),
+			Files:   files,
+		},
+	)
+
+	if err != nil {
+		return errors.Wrap(err, "failed to encode cache entry")
+	}
+
+	if err := os.MkdirAll(c.directory, os.ModePerm); err != nil {
+		return errors.Wrap(err, "failed to create cache directory")
+	}
+
+	return os.WriteFile(c.getPath(key), b, 0644)
+}
+
+func (c *Cache) Clean() error {
+	return os.RemoveAll(c.directory)
+}
+
+// getOutputTypes returns the types of the outputs which are not restored
+// by json.
+func getOutputTypes(outputs Outputs) map[string]string {
+	types := map[string]string{}
+
+	for name, value := range outputs {
+		switch value.(type) {
+		case int:
+			types[name] = "int"
+		case []string:
+			types[name] = "[]string"
+		}
+	}
+
+	return types
+}
+
+func restoreOutputTypes(outputs Outputs, types map[string]string) {
+	for name, typ := range types {
+		switch value := outputs[name].(type) {
+		case float64:
+			if typ == "int" {
+				outputs[name] = int(value)
+			}
+		case []any:
+			if typ == "[]string" {
+				outputs[name] = slicex.ToString(value)
+			}
+		}
+	}
+}
+
+func (c *Cache) getPath(key string) string {
+	return path.Join(c.directory, key+".json")
+}
+
+// hashFiles returns the sha256 hashes of all regular files matching the
+// patterns, keyed by their path.
+func hashFiles(patterns []string) (map[string]string, error) {
+	files := []string{}
+
+	for _, pattern := range patterns {
+		matches, err := filepathx.Glob(pattern)
+
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, matches...)
+	}
+
+	sort.Strings(files)
+
+	hashes := map[string]string{}
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		hash, err := hashFile(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hashes[file] = hash
+	}
+
+	return hashes, nil
+}
+
+func hashFile(file string) (string, error) {
+	return hashFileWith(file, "sha256")
+}
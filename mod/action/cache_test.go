@@ -0,0 +1,185 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+var cacheRuns int32
+
+func init() {
+	MustRegister(
+		"test.count", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			atomic.AddInt32(&cacheRuns, 1)
+
+			return Outputs{
+				"exit_code": 0,
+				"files":     []string{"a", "b"},
+				"value":     params["value"],
+			}, nil
+		},
+	)
+}
+
+func TestCache(t *testing.T) {
+	directory := t.TempDir()
+	input := filepath.Join(directory, "input.txt")
+	output := filepath.Join(directory, "output.txt")
+
+	writeTestFile(t, input, "input")
+	writeTestFile(t, output, "output")
+
+	cache := NewCache(filepath.Join(directory, "cache"))
+	step := &Step{Type: "test.count", Inputs: []string{input}, Outputs: []string{output}}
+	params := map[string]any{"value": "a"}
+
+	key, err := cache.Key(step, params, nil)
+
+	if err != nil || key == "" {
+		t.Fatalf("Key() got = %q, error = %v", key, err)
+	}
+
+	if _, ok, err := cache.Get(key, step); ok || err != nil {
+		t.Fatalf("Get() before Put() got ok = %v, error = %v", ok, err)
+	}
+
+	outputs := Outputs{"exit_code": 0, "files": []string{"a", "b"}, "value": "a"}
+
+	if err := cache.Put(key, step, outputs); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	entry, ok, err := cache.Get(key, step)
+
+	if !ok || err != nil {
+		t.Fatalf("Get() got ok = %v, error = %v", ok, err)
+	}
+
+	if !reflect.DeepEqual(entry.Outputs, outputs) {
+		t.Errorf("Get() got outputs %#v, want %#v", entry.Outputs, outputs)
+	}
+
+	t.Run("params", func(t *testing.T) {
+		other, err := cache.Key(step, map[string]any{"value": "b"}, nil)
+
+		if err != nil || other == key {
+			t.Errorf("Key() with other params got = %q, error = %v", other, err)
+		}
+	})
+
+	t.Run("no inputs", func(t *testing.T) {
+		other, err := cache.Key(&Step{Type: "test.count"}, params, nil)
+
+		if err != nil || other != "" {
+			t.Errorf("Key() without inputs got = %q, error = %v", other, err)
+		}
+	})
+
+	t.Run("changed output", func(t *testing.T) {
+		writeTestFile(t, output, "changed")
+
+		if _, ok, err := cache.Get(key, step); ok || err != nil {
+			t.Errorf("Get() got ok = %v, error = %v", ok, err)
+		}
+	})
+
+	t.Run("changed input", func(t *testing.T) {
+		writeTestFile(t, input, "changed")
+
+		other, err := cache.Key(step, params, nil)
+
+		if err != nil || other == key {
+			t.Errorf("Key() with changed input got = %q, error = %v", other, err)
+		}
+	})
+}
+
+func TestExecuter_Execute_Cache(t *testing.T) {
+	directory := t.TempDir()
+	input := filepath.Join(directory, "input.txt")
+
+	writeTestFile(t, input, "input")
+
+	list := &List{
+		Actions: map[string]*Action{
+			"test": {
+				Steps: map[string]*Step{
+					"count": {Type: "test.count", Inputs: []string{input}, Params: map[string]any{"value": "a"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		cache *Cache
+		setup func()
+		runs  int32
+	}{
+		{"miss", NewCache(filepath.Join(directory, "cache")), func() {}, 1},
+		{"hit", NewCache(filepath.Join(directory, "cache")), func() {}, 0},
+		{"invalidated", NewCache(filepath.Join(directory, "cache")), func() { writeTestFile(t, input, "changed") }, 1},
+		{"no cache", nil, func() {}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			atomic.StoreInt32(&cacheRuns, 0)
+
+			e := NewExecuter(list, Options{Cache: tt.cache})
+
+			report, err := e.Execute(context.Background(), "test")
+
+			if err != nil || !report.Succeeded() {
+				t.Fatalf("Execute() error = %v, succeeded = %v", err, report != nil && report.Succeeded())
+			}
+
+			if runs := atomic.LoadInt32(&cacheRuns); runs != tt.runs {
+				t.Errorf("Execute() ran the step %d times, want %d", runs, tt.runs)
+			}
+
+			for reference, want := range map[string]any{"count.exit_code": 0, "count.files": []string{"a", "b"}} {
+				got, err := e.GetOutput("test", reference)
+
+				if err != nil || !reflect.DeepEqual(got, want) {
+					t.Errorf("GetOutput(%s) got = %#v, want %#v, error = %v", reference, got, want, err)
+				}
+			}
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, file string, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
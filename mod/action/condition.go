@@ -0,0 +1,164 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"encoding/json"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/chapterjason/j3n/mod/expression"
+	"github.com/chapterjason/j3n/mod/version"
+	"github.com/chapterjason/j3n/modx/gitx"
+)
+
+var (
+	ErrConditionNotMet   = errors.New("condition not met")
+	ErrDependencySkipped = errors.New("dependency skipped")
+	ErrUnknownIdentifier = errors.New("unknown identifier")
+	ErrUnknownSkipRule   = errors.New("unknown skip rule")
+)
+
+// SkipRule decides what happens to a step whose dependency has been skipped
+// because its condition was not met.
+type SkipRule string
+
+const (
+	// SkipRuleRun runs the step as if the dependency succeeded.
+	SkipRuleRun SkipRule = "run"
+	// SkipRuleSkip skips the step as well.
+	SkipRuleSkip SkipRule = "skip"
+
+	DefaultSkipRule = SkipRuleRun
+)
+
+func (r *SkipRule) UnmarshalJSON(bytes []byte) error {
+	var s string
+
+	if err := json.Unmarshal(bytes, &s); err != nil {
+		return err
+	}
+
+	if s != string(SkipRuleRun) && s != string(SkipRuleSkip) {
+		return errors.Wrap(ErrUnknownSkipRule, s)
+	}
+
+	*r = SkipRule(s)
+
+	return nil
+}
+
+// checkCondition returns whether the "if" expression is truthy and the
+// "unless" expression is not. Empty expressions are ignored.
+func (e *Executer) checkCondition(actionName string, step *Step, ifExpression string, unlessExpression string) (bool, error) {
+	variables := e.getConditionVariables(actionName, step)
+
+	if ifExpression != "" {
+		ok, err := expression.Evaluate(ifExpression, variables)
+
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	if unlessExpression != "" {
+		ok, err := expression.Evaluate(unlessExpression, variables)
+
+		if err != nil || ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// getConditionVariables returns the identifiers available in conditions:
+// "os", "arch", "env.NAME", "git.branch", "git.commit", "version" with its
+// parts like "version.major", parameters like "param.env" and the outputs
+// of the steps of the action like "fmt.stdout". Outputs of steps which did
+// not run are null. The variables of a variant like "matrix.go" are
+// available in its conditions.
+func (e *Executer) getConditionVariables(actionName string, step *Step) expression.Variables {
+	return func(name string) (any, error) {
+		if step != nil {
+			if v, ok := step.variables[name]; ok {
+				return v, nil
+			}
+		}
+
+		switch name {
+		case "os":
+			return runtime.GOOS, nil
+		case "arch":
+			return runtime.GOARCH, nil
+		case "git.branch":
+			return gitx.GetBranch()
+		case "git.commit":
+			return gitx.GetCommit()
+		case "version", "version.major", "version.minor", "version.patch", "version.prerelease", "version.build":
+			v, err := version.Get()
+
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to get version")
+			}
+
+			switch name {
+			case "version.major":
+				return int(v.Major), nil
+			case "version.minor":
+				return int(v.Minor), nil
+			case "version.patch":
+				return int(v.Patch), nil
+			case "version.prerelease":
+				return strings.Join(v.Prerelease, "."), nil
+			case "version.build":
+				return strings.Join(v.Build, "."), nil
+			}
+
+			return v.String(), nil
+		}
+
+		if v, ok := e.getParameter(actionName, name); ok {
+			return v, nil
+		}
+
+		if strings.HasPrefix(name, "env.") {
+			return os.Getenv(strings.TrimPrefix(name, "env.")), nil
+		}
+
+		if v, err := e.storage.Resolve(actionName, name); err == nil {
+			return v, nil
+		}
+
+		if i := strings.LastIndex(name, "."); i > 0 {
+			if _, ok := e.list.Actions[actionName].Steps[name[:i]]; ok {
+				return nil, nil
+			}
+		}
+
+		return nil, errors.Wrap(ErrUnknownIdentifier, name)
+	}
+}
@@ -23,8 +23,14 @@
 package action
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -32,78 +38,184 @@ import (
 	"github.com/chapterjason/j3n/mod/topology"
 )
 
-var ErrOutputNotFound = errors.New("output not found")
+var (
+	ErrOutputNotFound   = errors.New("output not found")
+	ErrCancelled        = errors.New("cancelled")
+	ErrDependencyFailed = errors.New("dependency failed")
+	ErrStopped          = errors.New("stopped after a failure")
+)
+
+type Options struct {
+	// Jobs limits the number of steps running at the same time, zero means
+	// the number of CPUs.
+	Jobs int
+	// Policy overrides the policy configured on the actions.
+	Policy Policy
+	// Cache is used to skip steps whose inputs did not change, nil disables
+	// caching.
+	Cache *Cache
+	// OutputMode overrides the output mode configured on the steps.
+	OutputMode OutputMode
+	// Printer prints the output of the steps, defaults to stdout.
+	Printer *Printer
+	// Parameters are the bound parameters by action, see
+	// Action.BindParameters. Actions without parameters get their defaults.
+	Parameters map[string]map[string]any
+}
 
 type Executer struct {
 	list    *List
-	storage map[string]any
+	options Options
+	storage *Storage
+	jobs    chan struct{}
+	mutex   sync.Mutex
+	mutexes map[string]chan struct{}
+	// parameters are the values of the parameters by action of the running
+	// execution.
+	parameters map[string]map[string]any
 }
 
-func NewExecuter(list *List) *Executer {
+func NewExecuter(list *List, options Options) *Executer {
+	if options.Printer == nil {
+		options.Printer = NewPrinter(os.Stdout, false)
+	}
+
+	if options.Jobs == 0 {
+		options.Jobs = runtime.NumCPU()
+	}
+
 	return &Executer{
 		list:    list,
-		storage: make(map[string]any),
+		options: options,
+		storage: NewStorage(),
+		jobs:    make(chan struct{}, options.Jobs),
+		mutexes: make(map[string]chan struct{}),
+
+		parameters: make(map[string]map[string]any),
 	}
 }
 
-func (e *Executer) Execute(actionName string) (map[string]map[string]error, error) {
-	if _, ok := e.list.Actions[actionName]; !ok {
-		return nil, fmt.Errorf("action %s not found", actionName)
+// Execute executes the action and the steps it needs, which are the steps
+// of the actions it depends on and the steps of other actions its steps
+// reference.
+func (e *Executer) Execute(ctx context.Context, actionName string) (*Report, error) {
+	return e.ExecuteSelection(ctx, actionName, e.list.GetSelection(actionName))
+}
+
+// ExecuteSelection executes the action and its dependencies like Execute,
+// but only runs the selected steps. Steps which are not selected are neither
+// run nor reported and count as succeeded for their dependents.
+func (e *Executer) ExecuteSelection(ctx context.Context, actionName string, selection Selection) (*Report, error) {
+	root, err := e.list.GetAction(actionName)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "action %s", actionName)
 	}
 
-	ldg := e.list.GetGraph()
+	adg, err := e.prepare(actionName)
 
-	if ldg.IsCyclic() {
-		return nil, errors.New("cyclic dependency detected")
+	if err != nil {
+		return nil, err
 	}
 
-	adg := topology.NewDependencyGraph()
-	adg.Add(ldg, actionName)
+	report := NewReport()
+	keys := []string{}
 
-	results := map[string]map[string]error{}
+	for _, key := range adg.GetKeys() {
+		if selection.IncludesAction(key) {
+			keys = append(keys, key)
+		}
+	}
 
-	for items := range adg.Iterate() {
-		wg := sync.WaitGroup{}
+	actions := newScheduler(adg, keys, e.getPolicy(root))
+	actions.rule = func(actionName string) SkipRule {
+		return e.getSkipRule(e.list.Actions[actionName], nil)
+	}
 
-		actionNames := []string{}
+	actions.run(
+		ctx,
+		func(ctx context.Context, actionName string) Status {
+			action := e.list.Actions[actionName]
+			ok, err := e.checkCondition(actionName, nil, action.If, action.Unless)
 
-		for _, item := range items {
-			wg.Add(1)
+			if err != nil {
+				report.Add(e.skipAction(actionName, selection[actionName], StatusFailed, errors.Wrap(err, "condition"))...)
 
-			actionNames = append(actionNames, item)
+				return StatusFailed
+			}
 
-			go func(actionName string) {
-				if ers := e.ExecuteAction(actionName); ers != nil {
-					results[actionName] = ers
-				}
+			if !ok {
+				log.Infof("skipping action %s, condition not met", actionName)
 
-				wg.Done()
-			}(item)
-		}
+				report.Add(e.skipAction(actionName, selection[actionName], StatusSkipped, ErrConditionNotMet)...)
+
+				return StatusSkipped
+			}
 
-		wg.Wait()
+			results := e.executeAction(ctx, actionName, selection[actionName])
 
-		ers := map[string]map[string]error{}
+			report.Add(results...)
 
-		for _, actionName := range actionNames {
-			for outputName, err := range results[actionName] {
-				if ers[actionName] == nil {
-					ers[actionName] = map[string]error{}
+			status := summarize(results)
+
+			if status == StatusSucceeded {
+				if err := e.publishOutputs(actionName); err != nil {
+					report.Add(&Result{Action: actionName, Status: StatusFailed, Error: errors.Wrap(err, "outputs")})
+
+					return StatusFailed
 				}
+			}
 
-				ers[actionName][outputName] = err
+			return status
+		},
+		func(actionName string, status Status, reason error) {
+			report.Add(e.skipAction(actionName, selection[actionName], status, reason)...)
+		},
+	)
+
+	return report, nil
+}
+
+// prepare validates the actions and binds the parameters of the action and
+// its dependencies, it returns the graph of the action and its dependencies.
+func (e *Executer) prepare(actionName string) (*topology.DependencyGraph, error) {
+	if err := e.list.Validate(); err != nil {
+		return nil, err
+	}
+
+	adg := topology.NewDependencyGraph()
+	adg.Add(e.list.GetGraph(), actionName)
+
+	// the parameters are bound before anything is executed, so a missing
+	// parameter does not fail an action halfway.
+	for _, key := range adg.GetKeys() {
+		values, ok := e.options.Parameters[key]
+
+		if !ok {
+			var err error
+
+			values, err = e.list.Actions[key].BindParameters(nil, nil)
+
+			if err != nil {
+				return nil, errors.Wrapf(err, "action %s", key)
 			}
 		}
 
-		if len(ers) > 0 {
-			return ers, nil
-		}
+		e.mutex.Lock()
+		e.parameters[key] = values
+		e.mutex.Unlock()
 	}
 
-	return nil, nil
+	return adg, nil
 }
 
-func (e *Executer) ExecuteStep(action *Action, stepName string) error {
+func (e *Executer) ExecuteStep(ctx context.Context, actionName string, stepName string) error {
+	action, err := e.list.GetAction(actionName)
+
+	if err != nil {
+		return err
+	}
+
 	step, err := action.GetStep(stepName)
 
 	if err != nil {
@@ -117,100 +229,458 @@ func (e *Executer) ExecuteStep(action *Action, stepName string) error {
 	if step.Input != "" {
 		var err error
 
-		input, err = e.GetOutput(step.Input)
+		input, err = e.GetOutput(actionName, step.Input)
 
 		if err != nil {
 			return errors.Wrapf(err, "failed to get input %s", step.Input)
 		}
 	}
 
+	if step.Type == StepTypeVariants {
+		return e.collectVariants(actionName, stepName, step)
+	}
+
 	stepRunner, ok := Steps[step.Type]
 
 	if !ok {
 		return fmt.Errorf("no runner for step %s and type %s", stepName, step.Type)
 	}
 
-	out, err := stepRunner(input, step.Params)
+	variables := e.getVariables(actionName, step)
+
+	params, err := ExpandParams(step.Params, variables)
+
+	if err != nil {
+		return errors.Wrapf(err, "failed to expand params of step %s", stepName)
+	}
+
+	var key string
+
+	if e.options.Cache != nil {
+		key, err = e.options.Cache.Key(step, params, input)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if key != "" {
+		entry, ok, err := e.options.Cache.Get(key, step)
+
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			log.Infof("step %s is up to date", stepName)
+
+			return e.storeOutputs(actionName, stepName, entry.Outputs)
+		}
+	}
+
+	ctx = WithStepOutput(
+		ctx, &StepOutput{
+			Mode:    e.getOutputMode(step),
+			Prefix:  actionName + "/" + stepName,
+			Printer: e.options.Printer,
+		},
+	)
+	ctx = WithVariables(ctx, variables)
+
+	out, err := e.retry(
+		ctx, actionName+"/"+stepName, step.Retry, func() (any, error) {
+			ctx := ctx
+
+			if step.Timeout > 0 {
+				var cancel context.CancelFunc
+
+				ctx, cancel = context.WithTimeout(ctx, time.Duration(step.Timeout))
+				defer cancel()
+			}
+
+			out, err := stepRunner(ctx, input, params)
+
+			if err != nil {
+				return nil, contextError(ctx, err)
+			}
+
+			return out, nil
+		},
+	)
 
 	if err != nil {
 		return err
 	}
 
+	outputs := toOutputs(out)
+
+	if err := e.storeOutputs(actionName, stepName, outputs); err != nil {
+		return err
+	}
+
+	if key != "" {
+		if err := e.options.Cache.Put(key, step, outputs); err != nil {
+			return errors.Wrapf(err, "failed to cache step %s", stepName)
+		}
+	}
+
+	log.Debugf("step %s executed", stepName)
+
+	return nil
+}
+
+func (e *Executer) storeOutputs(actionName string, stepName string, outputs Outputs) error {
+	e.storage.Publish(actionName, stepName, outputs)
+
+	step := e.list.Actions[actionName].Steps[stepName]
+
 	if step.Output != "" {
-		if out == nil {
+		out, ok := outputs[DefaultOutput]
+
+		if !ok || out == nil {
 			return fmt.Errorf("output of step %s is nil", stepName)
 		}
 
-		e.storage[step.Output] = out
+		e.storage.Set(step.Output, out)
 	}
 
-	log.Debugf("step %s executed", stepName)
+	return nil
+}
+
+// publishOutputs resolves the outputs of the action and publishes them for
+// the steps of other actions.
+func (e *Executer) publishOutputs(actionName string) error {
+	action := e.list.Actions[actionName]
+
+	if len(action.Outputs) == 0 {
+		return nil
+	}
+
+	outputs := Outputs{}
+
+	for name, reference := range action.Outputs {
+		v, err := e.storage.Resolve(actionName, reference)
+
+		if err != nil {
+			return errors.Wrapf(err, "output %s", name)
+		}
+
+		outputs[name] = v
+	}
+
+	e.storage.PublishAction(actionName, outputs)
 
 	return nil
 }
 
-func (e *Executer) GetOutput(key string) (any, error) {
-	v, ok := e.storage[key]
+// getVariables returns the variables for the placeholders in the params of
+// a step, which are the variables of its variant, the outputs of other steps
+// and the builtin variables.
+func (e *Executer) getVariables(actionName string, step *Step) Variables {
+	return func(name string) (any, error) {
+		if v, ok := step.variables[name]; ok {
+			return v, nil
+		}
 
-	if !ok {
-		return nil, ErrOutputNotFound
+		if v, ok := e.getParameter(actionName, name); ok {
+			return v, nil
+		}
+
+		if v, err := e.storage.Resolve(actionName, name); err == nil {
+			return v, nil
+		}
+
+		return BuiltinVariables(name)
 	}
+}
+
+// getParameter returns the value of a reference like "param.env" to a
+// parameter of the action.
+func (e *Executer) getParameter(actionName string, reference string) (any, bool) {
+	if !strings.HasPrefix(reference, "param.") {
+		return nil, false
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	v, ok := e.parameters[actionName][strings.TrimPrefix(reference, "param.")]
+
+	return v, ok
+}
 
-	return v, nil
+// GetOutput returns the value of a reference made by a step of the given
+// action, see Storage.Resolve.
+func (e *Executer) GetOutput(actionName string, reference string) (any, error) {
+	return e.storage.Resolve(actionName, reference)
 }
 
-func (e *Executer) ExecuteAction(actionName string) map[string]error {
+func (e *Executer) ExecuteAction(ctx context.Context, actionName string) []*Result {
+	return e.executeAction(ctx, actionName, nil)
+}
+
+// executeAction executes the selected steps of the action, nil executes all
+// steps.
+func (e *Executer) executeAction(ctx context.Context, actionName string, selected map[string]bool) []*Result {
 	log.Infof("executing action %s", actionName)
 
 	action, err := e.list.GetAction(actionName)
 
 	if err != nil {
-		return map[string]error{actionName: err}
+		return []*Result{{Action: actionName, Status: StatusFailed, Error: err}}
+	}
+
+	if err := action.Validate("actions." + actionName); err != nil {
+		return []*Result{{Action: actionName, Status: StatusFailed, Error: err}}
 	}
 
 	sdg := action.GetGraph()
 
-	if sdg.IsCyclic() {
-		return map[string]error{actionName: errors.New("cyclic dependency")}
+	if action.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(action.Timeout))
+		defer cancel()
 	}
 
-	results := map[string]error{}
+	var slots chan struct{}
+
+	if action.MaxParallel > 0 {
+		slots = make(chan struct{}, action.MaxParallel)
+	}
 
-	for items := range sdg.Iterate() {
-		wg := sync.WaitGroup{}
+	results := []*Result{}
+	resultsMutex := sync.Mutex{}
+	keys := []string{}
 
-		stepNames := []string{}
+	for stepName := range action.Steps {
+		if selected == nil || selected[stepName] {
+			keys = append(keys, stepName)
+		}
+	}
 
-		for _, item := range items {
-			wg.Add(1)
+	steps := newScheduler(sdg, keys, e.getPolicy(action))
+	steps.rule = func(stepName string) SkipRule {
+		return e.getSkipRule(action, action.Steps[stepName])
+	}
 
-			stepNames = append(stepNames, item)
+	steps.run(
+		ctx,
+		func(ctx context.Context, stepName string) Status {
+			result := &Result{Action: actionName, Step: stepName}
+			step := action.Steps[stepName]
 
-			go func(action *Action, stepName string) {
-				if err := e.ExecuteStep(action, stepName); err != nil {
-					results[stepName] = err
-				}
+			ok, err := e.checkCondition(actionName, step, step.If, step.Unless)
 
-				wg.Done()
-			}(action, item)
-		}
+			if err != nil {
+				err = errors.Wrap(err, "condition")
+			} else if !ok {
+				log.Infof("skipping step %s/%s, condition not met", actionName, stepName)
 
-		wg.Wait()
+				result.Status = StatusSkipped
+				result.Error = ErrConditionNotMet
 
-		ers := map[string]error{}
+				resultsMutex.Lock()
+				results = append(results, result)
+				resultsMutex.Unlock()
 
-		for _, stepName := range stepNames {
-			if err, ok := results[stepName]; ok {
-				ers[stepName] = err
+				return result.Status
 			}
+
+			var release func()
+
+			if err == nil {
+				release, err = e.acquire(ctx, step, slots)
+			}
+
+			if err == nil {
+				start := time.Now()
+				err = e.ExecuteStep(ctx, actionName, stepName)
+				result.Duration = time.Since(start)
+
+				release()
+			}
+
+			switch {
+			case err == nil:
+				result.Status = StatusSucceeded
+			case errors.Is(err, ErrCancelled):
+				result.Status = StatusCancelled
+				result.Error = err
+			default:
+				result.Status = StatusFailed
+				result.Error = err
+			}
+
+			resultsMutex.Lock()
+			results = append(results, result)
+			resultsMutex.Unlock()
+
+			return result.Status
+		},
+		func(stepName string, status Status, reason error) {
+			resultsMutex.Lock()
+			results = append(results, &Result{Action: actionName, Step: stepName, Status: status, Error: reason})
+			resultsMutex.Unlock()
+		},
+	)
+
+	log.Debugf("action %s executed", actionName)
+
+	return results
+}
+
+// acquire waits until the step acquired its mutex groups, a slot of the
+// action if given and a job slot of the executer. The returned function
+// releases all of them.
+func (e *Executer) acquire(ctx context.Context, step *Step, actionSlots chan struct{}) (func(), error) {
+	mutexes := append([]string{}, step.Mutex...)
+	sort.Strings(mutexes)
+
+	slots := []chan struct{}{}
+
+	for _, name := range mutexes {
+		slots = append(slots, e.getMutex(name))
+	}
+
+	if actionSlots != nil {
+		slots = append(slots, actionSlots)
+	}
+
+	slots = append(slots, e.jobs)
+
+	acquired := []chan struct{}{}
+
+	release := func() {
+		for i := len(acquired) - 1; i >= 0; i-- {
+			<-acquired[i]
+		}
+	}
+
+	for _, slot := range slots {
+		select {
+		case slot <- struct{}{}:
+			acquired = append(acquired, slot)
+		case <-ctx.Done():
+			release()
+
+			return nil, contextError(ctx, ctx.Err())
 		}
+	}
 
-		if len(ers) > 0 {
-			return ers
+	return release, nil
+}
+
+func (e *Executer) getMutex(name string) chan struct{} {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if _, ok := e.mutexes[name]; !ok {
+		e.mutexes[name] = make(chan struct{}, 1)
+	}
+
+	return e.mutexes[name]
+}
+
+// skipAction returns a result with the given status for every selected step
+// of an action which is not executed, nil selects all steps.
+func (e *Executer) skipAction(actionName string, selected map[string]bool, status Status, reason error) []*Result {
+	action, err := e.list.GetAction(actionName)
+
+	if err != nil {
+		return []*Result{{Action: actionName, Status: StatusFailed, Error: err}}
+	}
+
+	stepNames := []string{}
+
+	for stepName := range action.Steps {
+		if selected == nil || selected[stepName] {
+			stepNames = append(stepNames, stepName)
 		}
 	}
 
-	log.Debugf("action %s executed", actionName)
+	sort.Strings(stepNames)
 
-	return nil
+	results := []*Result{}
+
+	for _, stepName := range stepNames {
+		results = append(results, &Result{Action: actionName, Step: stepName, Status: status, Error: reason})
+	}
+
+	return results
+}
+
+func (e *Executer) getPolicy(action *Action) Policy {
+	if e.options.Policy != "" {
+		return e.options.Policy
+	}
+
+	if action.Policy != "" {
+		return action.Policy
+	}
+
+	return DefaultPolicy
+}
+
+// getSkipRule returns the rule of the step if given, else the one of the
+// action.
+func (e *Executer) getSkipRule(action *Action, step *Step) SkipRule {
+	if step != nil && step.OnSkippedDependency != "" {
+		return step.OnSkippedDependency
+	}
+
+	if action != nil && action.OnSkippedDependency != "" {
+		return action.OnSkippedDependency
+	}
+
+	return DefaultSkipRule
+}
+
+func (e *Executer) getOutputMode(step *Step) OutputMode {
+	if e.options.OutputMode != "" {
+		return e.options.OutputMode
+	}
+
+	if step.OutputMode != "" {
+		return step.OutputMode
+	}
+
+	return DefaultOutputMode
+}
+
+// summarize returns the status of an action from the results of its steps.
+// An action whose steps were all skipped counts as skipped.
+func summarize(results []*Result) Status {
+	status := StatusSucceeded
+	skipped := 0
+
+	for _, result := range results {
+		switch result.Status {
+		case StatusFailed:
+			return StatusFailed
+		case StatusCancelled:
+			status = StatusCancelled
+		case StatusSkipped:
+			skipped++
+		}
+	}
+
+	if status == StatusSucceeded && len(results) > 0 && skipped == len(results) {
+		return StatusSkipped
+	}
+
+	return status
+}
+
+// contextError reports an error of a step whose context is done as either
+// cancelled or timed out instead of the error returned by the step runner.
+func contextError(ctx context.Context, err error) error {
+	switch ctx.Err() {
+	case context.Canceled:
+		return ErrCancelled
+	case context.DeadlineExceeded:
+		return errors.Wrap(err, "timed out")
+	}
+
+	return err
 }
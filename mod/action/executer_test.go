@@ -0,0 +1,312 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	MustRegister(
+		"test.outputs", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			if sleep, ok := params["sleep"].(int); ok {
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(time.Duration(sleep) * time.Millisecond):
+				}
+			}
+
+			if params["fail"] == true {
+				return nil, fmt.Errorf("failed")
+			}
+
+			return Outputs{
+				DefaultOutput: params["value"],
+				"value":       params["value"],
+				"input":       input,
+			}, nil
+		},
+	)
+}
+
+func TestExecuter_Execute(t *testing.T) {
+	steps := map[string]*Step{
+		"collect": {
+			Type:         "test.outputs",
+			Dependencies: []string{},
+			Input:        "s3.value",
+			Output:       "collected",
+			Params:       map[string]any{"value": "collect"},
+		},
+	}
+
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("s%d", i)
+
+		steps[name] = &Step{Type: "test.outputs", Params: map[string]any{"value": name}}
+		steps["collect"].Dependencies = append(steps["collect"].Dependencies, name)
+	}
+
+	list := &List{
+		Actions: map[string]*Action{
+			"test": {Steps: steps},
+		},
+	}
+
+	e := NewExecuter(list, Options{Jobs: 4})
+
+	report, err := e.Execute(context.Background(), "test")
+
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if !report.Succeeded() || len(report.Results()) != 11 {
+		t.Fatalf("Execute() got %d results, succeeded = %v", len(report.Results()), report.Succeeded())
+	}
+
+	tests := map[string]any{
+		"s7.value":      "s7",
+		"collect.input": "s3",
+		"collected":     "collect",
+	}
+
+	for reference, want := range tests {
+		got, err := e.GetOutput("test", reference)
+
+		if err != nil {
+			t.Errorf("GetOutput(%s) error = %v", reference, err)
+		}
+
+		if got != want {
+			t.Errorf("GetOutput(%s) got = %v, want %v", reference, got, want)
+		}
+	}
+}
+
+func TestExecuter_Execute_KeepGoing(t *testing.T) {
+	list := &List{
+		Actions: map[string]*Action{
+			"test": {
+				Policy: PolicyKeepGoing,
+				Steps: map[string]*Step{
+					"fail":       {Type: "test.outputs", Params: map[string]any{"fail": true}},
+					"afterFail":  {Type: "test.outputs", Dependencies: []string{"fail"}},
+					"ok":         {Type: "test.outputs"},
+					"afterOk":    {Type: "test.outputs", Dependencies: []string{"ok"}},
+					"afterBoth":  {Type: "test.outputs", Dependencies: []string{"afterOk", "afterFail"}},
+					"standalone": {Type: "test.outputs"},
+				},
+			},
+		},
+	}
+
+	report, err := NewExecuter(list, Options{}).Execute(context.Background(), "test")
+
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	want := map[string]Status{
+		"fail":       StatusFailed,
+		"afterFail":  StatusSkipped,
+		"ok":         StatusSucceeded,
+		"afterOk":    StatusSucceeded,
+		"afterBoth":  StatusSkipped,
+		"standalone": StatusSucceeded,
+	}
+
+	for _, result := range report.Results() {
+		if result.Status != want[result.Step] {
+			t.Errorf("step %s got status %s, want %s", result.Step, result.Status, want[result.Step])
+		}
+	}
+}
+
+func TestExecuter_Execute_Policies(t *testing.T) {
+	tests := map[Policy]struct {
+		sleep   int
+		want    map[string]Status
+		reasons map[string]error
+	}{
+		PolicyFailFast: {
+			sleep:   5000,
+			want:    map[string]Status{"fail": StatusFailed, "slow": StatusCancelled, "afterFail": StatusSkipped, "afterSlow": StatusSkipped},
+			reasons: map[string]error{"slow": ErrCancelled, "afterFail": ErrDependencyFailed, "afterSlow": ErrDependencyFailed},
+		},
+		PolicyFinishLayer: {
+			sleep:   200,
+			want:    map[string]Status{"fail": StatusFailed, "slow": StatusSucceeded, "afterFail": StatusSkipped, "afterSlow": StatusSkipped},
+			reasons: map[string]error{"afterFail": ErrDependencyFailed, "afterSlow": ErrStopped},
+		},
+	}
+
+	for policy, tt := range tests {
+		t.Run(string(policy), func(t *testing.T) {
+			list := &List{
+				Actions: map[string]*Action{
+					"test": {
+						Policy: policy,
+						Steps: map[string]*Step{
+							"fail":      {Type: "test.outputs", Params: map[string]any{"fail": true, "sleep": 50}},
+							"slow":      {Type: "test.outputs", Params: map[string]any{"sleep": tt.sleep}},
+							"afterFail": {Type: "test.outputs", Dependencies: []string{"fail"}},
+							"afterSlow": {Type: "test.outputs", Dependencies: []string{"slow"}},
+						},
+					},
+				},
+			}
+
+			start := time.Now()
+
+			report, err := NewExecuter(list, Options{Jobs: 2}).Execute(context.Background(), "test")
+
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			if time.Since(start) > 2*time.Second {
+				t.Errorf("Execute() took %s, want the running step to be cancelled", time.Since(start))
+			}
+
+			// the cli exits with an error if the report did not succeed
+			if report.Succeeded() {
+				t.Errorf("Execute() succeeded, want a failed report")
+			}
+
+			for _, result := range report.Results() {
+				if result.Status != tt.want[result.Step] {
+					t.Errorf("step %s got status %s, want %s", result.Step, result.Status, tt.want[result.Step])
+				}
+
+				if reason, ok := tt.reasons[result.Step]; ok && !errors.Is(result.Error, reason) {
+					t.Errorf("step %s got reason %v, want %v", result.Step, result.Error, reason)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuter_Execute_Conditions(t *testing.T) {
+	list := &List{
+		Actions: map[string]*Action{
+			"test": {
+				Steps: map[string]*Step{
+					"check":   {Type: "test.outputs", Params: map[string]any{"value": ""}},
+					"fix":     {Type: "test.outputs", Dependencies: []string{"check"}, If: "check.value != ''"},
+					"run":     {Type: "test.outputs", Dependencies: []string{"fix"}},
+					"skip":    {Type: "test.outputs", Dependencies: []string{"fix"}, OnSkippedDependency: SkipRuleSkip},
+					"unless":  {Type: "test.outputs", Unless: "empty(check.value)"},
+					"invalid": {Type: "test.outputs", If: "unknown == 1"},
+				},
+			},
+		},
+	}
+
+	report, err := NewExecuter(list, Options{Policy: PolicyKeepGoing}).Execute(context.Background(), "test")
+
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	want := map[string]Status{
+		"check":   StatusSucceeded,
+		"fix":     StatusSkipped,
+		"run":     StatusSucceeded,
+		"skip":    StatusSkipped,
+		"unless":  StatusSkipped,
+		"invalid": StatusFailed,
+	}
+
+	for _, result := range report.Results() {
+		if result.Status != want[result.Step] {
+			t.Errorf("step %s got status %s, want %s", result.Step, result.Status, want[result.Step])
+		}
+	}
+}
+
+func TestExecuter_Execute_References(t *testing.T) {
+	list := &List{
+		Actions: map[string]*Action{
+			"build": {
+				Outputs: map[string]string{"binary": "compile.value"},
+				Steps: map[string]*Step{
+					"generate": {Type: "test.outputs", Params: map[string]any{"value": "generated"}},
+					"compile":  {Type: "test.outputs", Dependencies: []string{"generate"}, Params: map[string]any{"value": "bin/app"}},
+					"docs":     {Type: "test.outputs", Params: map[string]any{"value": "docs"}},
+				},
+			},
+			"deploy": {
+				Steps: map[string]*Step{
+					"upload": {Type: "test.outputs", Dependencies: []string{"build.compile"}, Input: "build.compile.value"},
+				},
+			},
+			"release": {
+				Steps: map[string]*Step{
+					"publish": {Type: "test.outputs", Input: "build.binary"},
+				},
+			},
+		},
+	}
+
+	e := NewExecuter(list, Options{})
+	report, err := e.Execute(context.Background(), "deploy")
+
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	got := []string{}
+
+	for _, result := range report.Results() {
+		got = append(got, result.Action+"."+result.Step)
+	}
+
+	sort.Strings(got)
+
+	if want := []string{"build.compile", "build.generate", "deploy.upload"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Execute() ran %v, want %v", got, want)
+	}
+
+	if v, err := e.GetOutput("deploy", "upload.input"); err != nil || v != "bin/app" {
+		t.Errorf("GetOutput(upload.input) got = %v, %v, want bin/app", v, err)
+	}
+
+	report, err = e.Execute(context.Background(), "release")
+
+	if err != nil || !report.Succeeded() {
+		t.Fatalf("Execute() error = %v, succeeded = %v", err, report.Succeeded())
+	}
+
+	if v, err := e.GetOutput("release", "publish.input"); err != nil || v != "bin/app" {
+		t.Errorf("GetOutput(publish.input) got = %v, %v, want bin/app", v, err)
+	}
+}
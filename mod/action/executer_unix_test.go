@@ -0,0 +1,157 @@
+//go:build !windows
+
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestExecuter_Execute_Timeouts(t *testing.T) {
+	tests := map[string]struct {
+		action func(step *Step) *Action
+		cancel time.Duration
+		status Status
+		error  string
+	}{
+		"step timeout": {
+			action: func(step *Step) *Action {
+				step.Timeout = Duration(200 * time.Millisecond)
+
+				return &Action{Steps: map[string]*Step{"sleep": step}}
+			},
+			status: StatusFailed,
+			error:  "timed out",
+		},
+		"action timeout": {
+			action: func(step *Step) *Action {
+				return &Action{Timeout: Duration(200 * time.Millisecond), Steps: map[string]*Step{"sleep": step}}
+			},
+			status: StatusFailed,
+			error:  "timed out",
+		},
+		"cancelled": {
+			action: func(step *Step) *Action {
+				return &Action{Steps: map[string]*Step{"sleep": step}}
+			},
+			cancel: 200 * time.Millisecond,
+			status: StatusCancelled,
+			error:  ErrCancelled.Error(),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				pidFile := filepath.Join(t.TempDir(), "pid")
+
+				// the child of the shell must be killed with it, as it is
+				// part of the process group of the step.
+				step := &Step{
+					Type:   "shell",
+					Params: map[string]any{"script": "sleep 30 & echo $! > " + pidFile + "; wait"},
+				}
+
+				list := &List{Actions: map[string]*Action{"test": tt.action(step)}}
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+
+				if tt.cancel > 0 {
+					time.AfterFunc(tt.cancel, cancel)
+				}
+
+				start := time.Now()
+				report, err := NewExecuter(list, Options{}).Execute(ctx, "test")
+
+				if err != nil {
+					t.Fatalf("Execute() error = %v", err)
+				}
+
+				if elapsed := time.Since(start); elapsed > 10*time.Second {
+					t.Errorf("Execute() took %s, want the step to be stopped", elapsed)
+				}
+
+				results := report.Results()
+
+				if len(results) != 1 || results[0].Status != tt.status {
+					t.Fatalf("Execute() got results %v, want status %s", results, tt.status)
+				}
+
+				if results[0].Error == nil || !strings.Contains(results[0].Error.Error(), tt.error) {
+					t.Errorf("Execute() got error %v, want %q", results[0].Error, tt.error)
+				}
+
+				b, err := os.ReadFile(pidFile)
+
+				if err != nil {
+					t.Fatalf("failed to read pid of the child: %v", err)
+				}
+
+				pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				deadline := time.Now().Add(5 * time.Second)
+
+				for isRunning(pid) {
+					if time.Now().After(deadline) {
+						_ = syscall.Kill(pid, syscall.SIGKILL)
+
+						t.Fatalf("child process %d is still running", pid)
+					}
+
+					time.Sleep(10 * time.Millisecond)
+				}
+			},
+		)
+	}
+}
+
+// isRunning returns whether the process exists and is not a zombie waiting
+// to be reaped.
+func isRunning(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+
+	b, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+
+	if err != nil {
+		return true
+	}
+
+	fields := strings.Fields(string(b[strings.LastIndex(string(b), ")")+1:]))
+
+	return len(fields) == 0 || fields[0] != "Z"
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// StepTypeVariants is the type of the step which replaces an expanded step.
+// It depends on all variants and outputs the list of their outputs, so
+// dependents and references of the expanded step keep working.
+const StepTypeVariants = "variants"
+
+var (
+	ErrVariantConflict = errors.New("variant conflicts with an existing step")
+	ErrInternalType    = errors.New("step type is internal")
+
+	variantNameExpression = regexp.MustCompile(`[^\w\-]+`)
+)
+
+// variant is one of the steps an expanded step is replaced with. The name
+// of the variant is the name of the step followed by the suffix.
+type variant struct {
+	suffix string
+	step   *Step
+}
+
+// Expand returns a copy of the list in which the steps of all actions are
+// expanded, see Action.Expand.
+func (l *List) Expand() (*List, error) {
+	list := &List{Actions: map[string]*Action{}}
+
+	for actionName, action := range l.Actions {
+		expanded, err := action.Expand()
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "action %s", actionName)
+		}
+
+		list.Actions[actionName] = expanded
+	}
+
+	return list, nil
+}
+
+// Expand returns a copy of the action in which steps with a matrix or like a
+// "go.build" step with several platforms are replaced by one step per
+// variant.
+func (a *Action) Expand() (*Action, error) {
+	action := *a
+	action.Steps = map[string]*Step{}
+
+	for stepName, step := range a.Steps {
+		if step.Type == StepTypeVariants {
+			return nil, errors.Wrapf(ErrInternalType, "step %s", stepName)
+		}
+
+		variants, err := expandStep(step)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "step %s", stepName)
+		}
+
+		if len(variants) == 1 && variants[0].suffix == "" {
+			action.Steps[stepName] = variants[0].step
+
+			continue
+		}
+
+		// the outputs of the variants are only stored under the output key
+		// as the list of the aggregate.
+		aggregate := &Step{Type: StepTypeVariants, Output: step.Output}
+
+		for _, v := range variants {
+			name := stepName + "_" + variantNameExpression.ReplaceAllString(v.suffix, "-")
+
+			// suffixes like "1.18" and "1-18" result in the same name, which
+			// must not replace a variant added before.
+			_, exists := action.Steps[name]
+
+			if _, ok := a.Steps[name]; ok || exists {
+				return nil, errors.Wrapf(ErrVariantConflict, "step %s", name)
+			}
+
+			variantStep := *v.step
+			variantStep.Output = ""
+
+			action.Steps[name] = &variantStep
+			aggregate.Dependencies = append(aggregate.Dependencies, name)
+		}
+
+		sort.Strings(aggregate.Dependencies)
+
+		action.Steps[stepName] = aggregate
+	}
+
+	return &action, nil
+}
+
+// expandStep returns the variants of the step, which is the step itself if
+// it is not expanded. The matrix is expanded before the step type.
+func expandStep(step *Step) ([]variant, error) {
+	if step.Matrix != nil {
+		variants, err := expandMatrix(step)
+
+		if err != nil {
+			return nil, err
+		}
+
+		expanded := []variant{}
+
+		for _, v := range variants {
+			typeVariants, err := expandStepType(v.step)
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, tv := range typeVariants {
+				suffix := v.suffix
+
+				if tv.suffix != "" {
+					suffix += "_" + tv.suffix
+				}
+
+				expanded = append(expanded, variant{suffix: suffix, step: tv.step})
+			}
+		}
+
+		return expanded, nil
+	}
+
+	return expandStepType(step)
+}
+
+func expandStepType(step *Step) ([]variant, error) {
+	switch step.Type {
+	case "go.build":
+		return expandGoBuild(step)
+	}
+
+	return []variant{{step: step}}, nil
+}
+
+// withVariables returns a copy of the step with additional variables for
+// the placeholders in its params.
+func (s *Step) withVariables(variables map[string]any) *Step {
+	step := *s
+	step.variables = map[string]any{}
+
+	for name, value := range s.variables {
+		step.variables[name] = value
+	}
+
+	for name, value := range variables {
+		step.variables[name] = value
+	}
+
+	return &step
+}
+
+// collectVariants publishes the outputs of the variants of an expanded step
+// as the outputs of the step.
+func (e *Executer) collectVariants(actionName string, stepName string, step *Step) error {
+	outputs := []any{}
+	variants := map[string]any{}
+
+	for _, dep := range step.Dependencies {
+		output, err := e.storage.Resolve(actionName, dep+"."+DefaultOutput)
+
+		if err != nil {
+			output = nil
+		}
+
+		outputs = append(outputs, output)
+		variants[dep] = output
+	}
+
+	return e.storeOutputs(actionName, stepName, Outputs{DefaultOutput: outputs, "variants": variants})
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestAction_Expand(t *testing.T) {
+	action := &Action{
+		Steps: map[string]*Step{
+			"build": {
+				Type:   "go.build",
+				Output: "binaries",
+				Params: map[string]any{"name": "app", "platforms": []any{"linux/amd64", "windows/arm64"}},
+			},
+			"host": {Type: "go.build", Params: map[string]any{"name": "app", "goos": "plan9", "goarch": "386"}},
+			"pack": {Type: "print", Dependencies: []string{"build"}},
+		},
+	}
+
+	expanded, err := action.Expand()
+
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+
+	build := expanded.Steps["build"]
+
+	if build.Type != StepTypeVariants || build.Output != "binaries" || !reflect.DeepEqual(build.Dependencies, []string{"build_linux_amd64", "build_windows_arm64"}) {
+		t.Errorf("Expand() build = %+v", build)
+	}
+
+	windows := expanded.Steps["build_windows_arm64"]
+
+	if windows.Output != "" || windows.Params["goos"] != "windows" || windows.Params["platforms"] != nil {
+		t.Errorf("Expand() build_windows_arm64 = %+v", windows)
+	}
+
+	output, err := ExpandPlaceholders(windows.Params["output"].(string), (&Executer{storage: NewStorage()}).getVariables("test", windows))
+
+	if err != nil || output != "dist/app_windows_arm64.exe" {
+		t.Errorf("Expand() build_windows_arm64 output = %s, %v", output, err)
+	}
+
+	if host := expanded.Steps["host"]; host.Type != "go.build" || host.variables["GOOS"] != "plan9" {
+		t.Errorf("Expand() host = %+v", host)
+	}
+
+	if _, ok := action.Steps["build_linux_amd64"]; ok {
+		t.Errorf("Expand() modified the action")
+	}
+}
+
+func TestAction_Expand_Matrix(t *testing.T) {
+	var step Step
+
+	err := json.Unmarshal(
+		[]byte(`{
+			"type": "exec",
+			"matrix": {
+				"node": [16, 18],
+				"db": ["pg", "mysql"],
+				"exclude": [{"node": 16, "db": "mysql"}],
+				"include": [{"node": 18, "experimental": true}, {"node": 20, "db": "pg"}]
+			},
+			"params": {"command": "test", "args": ["{{matrix.node}}", "{{matrix.db}}"]}
+		}`), &step,
+	)
+
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	expanded, err := (&Action{Steps: map[string]*Step{"test": &step}}).Expand()
+
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+
+	want := []string{"test_mysql_true_18", "test_pg_16", "test_pg_20", "test_pg_true_18"}
+
+	if got := expanded.Steps["test"].Dependencies; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expand() variants = %v, want %v", got, want)
+	}
+
+	variant := expanded.Steps["test_mysql_true_18"]
+	params, err := ExpandParams(variant.Params, (&Executer{storage: NewStorage()}).getVariables("test", variant))
+
+	if err != nil || !reflect.DeepEqual(params["args"], []any{"18", "mysql"}) {
+		t.Errorf("ExpandParams() = %v, %v", params["args"], err)
+	}
+
+	if variant.Matrix != nil || variant.variables["matrix.experimental"] != true {
+		t.Errorf("Expand() variant = %+v", variant)
+	}
+}
+
+func TestAction_Expand_InternalType(t *testing.T) {
+	_, err := (&Action{Steps: map[string]*Step{"test": {Type: StepTypeVariants}}}).Expand()
+
+	if !errors.Is(err, ErrInternalType) {
+		t.Errorf("Expand() error = %v, want %v", err, ErrInternalType)
+	}
+}
+
+func TestAction_Expand_VariantConflict(t *testing.T) {
+	step := &Step{
+		Type:   "exec",
+		Matrix: &Matrix{Values: map[string][]any{"go": {"1.18", "1-18"}}},
+		Params: map[string]any{"command": "go"},
+	}
+
+	_, err := (&Action{Steps: map[string]*Step{"test": step}}).Expand()
+
+	if !errors.Is(err, ErrVariantConflict) {
+		t.Errorf("Expand() error = %v, want %v", err, ErrVariantConflict)
+	}
+}
+
+func TestAction_Expand_GoBuildDirectory(t *testing.T) {
+	action := &Action{
+		Steps: map[string]*Step{
+			"api": {Type: "go.build", Params: map[string]any{"directory": "services/api", "goos": "linux", "goarch": "amd64"}},
+			"web": {Type: "go.build", Params: map[string]any{"directory": "services/web", "package": "./cmd/server", "goos": "linux", "goarch": "amd64"}},
+		},
+	}
+
+	expanded, err := action.Expand()
+
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+
+	for stepName, want := range map[string]string{"api": "api", "web": "server"} {
+		if got := expanded.Steps[stepName].variables["NAME"]; got != want {
+			t.Errorf("Expand() %s got NAME %v, want %s", stepName, got, want)
+		}
+	}
+}
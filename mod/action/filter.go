@@ -0,0 +1,205 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/chapterjason/j3n/mod/topology"
+	"github.com/chapterjason/j3n/modx/slicex"
+)
+
+var (
+	ErrConflictingFilter = errors.New("only and from can not be used together")
+	ErrMissingInput      = errors.New("input is not provided by a selected step")
+)
+
+// StepFilter restricts the steps of an action which are executed.
+type StepFilter struct {
+	// Only selects the given steps instead of all steps of the action.
+	Only []string
+	// From selects the given step and all steps depending on it instead of
+	// all steps of the action.
+	From string
+	// Skip removes the given steps from the selection, even if other steps
+	// depend on them.
+	Skip []string
+	// Dependencies also selects the steps which the selected steps depend
+	// on, including the steps of the actions the action depends on.
+	Dependencies bool
+}
+
+// SelectSteps returns the steps of the action and its dependencies which
+// are selected by the filter. Every selected step must get its input from
+// another selected step.
+func (l *List) SelectSteps(actionName string, filter StepFilter) (Selection, error) {
+	action, err := l.GetAction(actionName)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "action %s", actionName)
+	}
+
+	if len(filter.Only) > 0 && filter.From != "" {
+		return nil, ErrConflictingFilter
+	}
+
+	names := append(append([]string{}, filter.Only...), filter.Skip...)
+
+	if filter.From != "" {
+		names = append(names, filter.From)
+	}
+
+	for _, name := range names {
+		if _, err := action.GetStep(name); err != nil {
+			return nil, errors.Wrapf(err, "step %s of action %s", name, actionName)
+		}
+	}
+
+	stepNames := action.GetStepNames()
+
+	switch {
+	case len(filter.Only) > 0:
+		stepNames = withVariants(action, filter.Only)
+	case filter.From != "":
+		stepNames = withVariants(action, dependents(action.GetGraph(), filter.From))
+	}
+
+	selection := Selection{}
+
+	if filter.Dependencies {
+		graph := l.GetStepGraph()
+		needed := topology.NewDependencyGraph()
+
+		for _, stepName := range stepNames {
+			needed.Add(graph, actionName+"."+stepName)
+		}
+
+		for _, key := range needed.GetKeys() {
+			name, stepName, _ := splitReference(key)
+
+			selection.Add(name, stepName)
+		}
+	} else {
+		selection.Add(actionName, stepNames...)
+	}
+
+	for _, stepName := range withVariants(action, filter.Skip) {
+		delete(selection[actionName], stepName)
+	}
+
+	if err := l.validateInputs(selection); err != nil {
+		return nil, err
+	}
+
+	return selection, nil
+}
+
+// withVariants returns the names of the steps and the names of the variants
+// of the steps which have been expanded, see List.Expand.
+func withVariants(action *Action, stepNames []string) []string {
+	names := append([]string{}, stepNames...)
+
+	for _, stepName := range stepNames {
+		if step, ok := action.Steps[stepName]; ok && step.Type == StepTypeVariants {
+			for _, dep := range step.Dependencies {
+				if !slicex.Contains(names, dep) {
+					names = append(names, dep)
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+// validateInputs returns an error if a selected step gets its input only
+// from steps which are not selected.
+func (l *List) validateInputs(selection Selection) error {
+	problems := []string{}
+
+	for _, actionName := range l.GetNames() {
+		action := l.Actions[actionName]
+
+		for _, stepName := range action.GetStepNames() {
+			step := action.Steps[stepName]
+
+			if !selection.Includes(actionName, stepName) || step.Input == "" {
+				continue
+			}
+
+			providers := l.getInputProviders(actionName, step.Input)
+			provided := len(providers) == 0
+
+			for _, key := range providers {
+				name, provider, _ := splitReference(key)
+
+				if selection.Includes(name, provider) {
+					provided = true
+				}
+			}
+
+			if !provided {
+				problems = append(problems, fmt.Sprintf("step %s of action %s needs the input %s of %s", stepName, actionName, step.Input, strings.Join(providers, " or ")))
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return errors.Wrap(ErrMissingInput, strings.Join(problems, ", "))
+}
+
+// getInputProviders returns the keys like "build.compile" of the steps which
+// provide an input like "compile.stdout", "build.compile.stdout",
+// "build.binary" or the output key of a step.
+func (l *List) getInputProviders(actionName string, input string) []string {
+	if stepName, _, ok := splitReference(input); ok {
+		if _, ok := l.Actions[actionName].Steps[stepName]; ok {
+			return []string{actionName + "." + stepName}
+		}
+	}
+
+	if keys := l.resolveStepReference(actionName, input); len(keys) > 0 {
+		return keys
+	}
+
+	keys := []string{}
+
+	for _, name := range l.GetNames() {
+		for stepName, step := range l.Actions[name].Steps {
+			if step.Output == input && !slicex.Contains(keys, name+"."+stepName) {
+				keys = append(keys, name+"."+stepName)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
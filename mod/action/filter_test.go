@@ -0,0 +1,101 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestList_SelectSteps(t *testing.T) {
+	list := &List{
+		Actions: map[string]*Action{
+			"prepare": {
+				Steps: map[string]*Step{"gen": {Type: "print"}},
+			},
+			"check": {
+				Dependencies: []string{"prepare"},
+				Steps: map[string]*Step{
+					"fmt":   {Type: "print"},
+					"tidy":  {Type: "print"},
+					"build": {Type: "print", Dependencies: []string{"fmt", "tidy"}, Output: "binary"},
+					"test":  {Type: "print", Dependencies: []string{"build"}, Input: "binary"},
+					"lint":  {Type: "print", Dependencies: []string{"fmt"}},
+				},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		filter StepFilter
+		want   Selection
+		err    error
+	}{
+		"all": {
+			filter: StepFilter{Dependencies: true},
+			want:   list.GetSelection("check"),
+		},
+		"only": {
+			filter: StepFilter{Only: []string{"lint"}},
+			want:   Selection{"check": {"lint": true}},
+		},
+		"only with dependencies": {
+			filter: StepFilter{Only: []string{"lint"}, Dependencies: true},
+			want:   Selection{"check": {"lint": true, "fmt": true}, "prepare": {"gen": true}},
+		},
+		"from": {
+			filter: StepFilter{From: "build"},
+			want:   Selection{"check": {"build": true, "test": true}},
+		},
+		"skip": {
+			filter: StepFilter{Skip: []string{"fmt", "tidy"}},
+			want:   Selection{"check": {"build": true, "test": true, "lint": true}},
+		},
+		"missing input": {
+			filter: StepFilter{Only: []string{"test"}},
+			err:    ErrMissingInput,
+		},
+		"unknown step": {
+			filter: StepFilter{Skip: []string{"vet"}},
+			err:    ErrStepNotFound,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				got, err := list.SelectSteps("check", tt.filter)
+
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("SelectSteps() error = %v, want %v", err, tt.err)
+				}
+
+				if tt.err == nil && !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("SelectSteps() got = %v, want %v", got, tt.want)
+				}
+			},
+		)
+	}
+}
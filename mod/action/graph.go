@@ -0,0 +1,381 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/chapterjason/j3n/mod/topology"
+)
+
+type GraphFormat string
+
+const (
+	GraphDot     GraphFormat = "dot"
+	GraphMermaid GraphFormat = "mermaid"
+	GraphASCII   GraphFormat = "ascii"
+)
+
+var (
+	GraphFormats = []GraphFormat{GraphDot, GraphMermaid, GraphASCII}
+
+	ErrUnknownGraphFormat = errors.New("unknown graph format")
+)
+
+func ParseGraphFormat(s string) (GraphFormat, error) {
+	for _, f := range GraphFormats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+
+	return "", errors.Wrap(ErrUnknownGraphFormat, s)
+}
+
+// graphView is a dependency graph prepared for rendering, with its layers
+// and the keys and edges which are part of cycles.
+type graphView struct {
+	name   string
+	prefix string
+	graph  *topology.DependencyGraph
+	keys   []string
+	layers [][]string
+	cycles [][]string
+	cyclic map[string]bool
+	// cyclicEdges are the edges from a dependency to its dependent which are
+	// part of a cycle.
+	cyclicEdges map[[2]string]bool
+}
+
+func newGraphView(name string, prefix string, graph *topology.DependencyGraph) *graphView {
+	keys := graph.GetKeys()
+	sort.Strings(keys)
+
+	view := &graphView{
+		name:        name,
+		prefix:      prefix,
+		graph:       graph,
+		keys:        keys,
+		layers:      graph.GetLayers(),
+		cycles:      graph.GetCycles(),
+		cyclic:      map[string]bool{},
+		cyclicEdges: map[[2]string]bool{},
+	}
+
+	for _, cycle := range view.cycles {
+		for i, key := range cycle {
+			view.cyclic[key] = true
+
+			if i > 0 {
+				view.cyclicEdges[[2]string{key, cycle[i-1]}] = true
+			}
+		}
+	}
+
+	return view
+}
+
+// edges returns the edges from every dependency to its dependents, which is
+// the order of execution.
+func (v *graphView) edges() [][2]string {
+	edges := [][2]string{}
+
+	for _, key := range v.keys {
+		deps := append([]string{}, v.graph.GetDependencies(key)...)
+		sort.Strings(deps)
+
+		for _, dep := range deps {
+			edges = append(edges, [2]string{dep, key})
+		}
+	}
+
+	return edges
+}
+
+// getGraphViews returns the view of the action graph followed by the views
+// of the step graphs. If names are given, only these actions and their
+// dependencies are included.
+func getGraphViews(list *List, names []string) ([]*graphView, error) {
+	graph := list.GetGraph()
+
+	if len(names) > 0 {
+		closure := topology.NewDependencyGraph()
+
+		for _, name := range names {
+			if !list.HasAction(name) {
+				return nil, errors.Wrapf(ErrActionNotFound, "action %s", name)
+			}
+
+			closure.Add(graph, name)
+		}
+
+		graph = closure
+	}
+
+	views := []*graphView{newGraphView("actions", "action", graph)}
+	actionNames := graph.GetKeys()
+	sort.Strings(actionNames)
+
+	for _, name := range actionNames {
+		if action, ok := list.Actions[name]; ok {
+			views = append(views, newGraphView(name, name, action.GetGraph()))
+		}
+	}
+
+	return views, nil
+}
+
+// RenderGraph writes the graph of the actions and the graph of the steps of
+// every action in the format, with their execution layers and cycles.
+func RenderGraph(w io.Writer, list *List, names []string, format GraphFormat) error {
+	views, err := getGraphViews(list, names)
+
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case GraphDot:
+		renderDot(w, views)
+	case GraphMermaid:
+		renderMermaid(w, views)
+	case GraphASCII:
+		renderASCII(w, views)
+	default:
+		return errors.Wrap(ErrUnknownGraphFormat, string(format))
+	}
+
+	return nil
+}
+
+func renderDot(w io.Writer, views []*graphView) {
+	fmt.Fprintln(w, "digraph j3n {")
+
+	for i, view := range views {
+		id := func(key string) string {
+			return fmt.Sprintf("%q", view.prefix+"/"+key)
+		}
+
+		fmt.Fprintf(w, "\tsubgraph cluster_%d {\n", i)
+		fmt.Fprintf(w, "\t\tlabel=%q;\n", view.name)
+
+		for _, key := range view.keys {
+			attributes := fmt.Sprintf("label=%q", key)
+
+			if view.cyclic[key] {
+				attributes += ", color=red, fontcolor=red"
+			}
+
+			fmt.Fprintf(w, "\t\t%s [%s];\n", id(key), attributes)
+		}
+
+		for _, edge := range view.edges() {
+			if view.cyclicEdges[edge] {
+				fmt.Fprintf(w, "\t\t%s -> %s [color=red];\n", id(edge[0]), id(edge[1]))
+			} else {
+				fmt.Fprintf(w, "\t\t%s -> %s;\n", id(edge[0]), id(edge[1]))
+			}
+		}
+
+		for j, layer := range view.layers {
+			ids := []string{}
+
+			for _, key := range layer {
+				ids = append(ids, id(key))
+			}
+
+			fmt.Fprintf(w, "\t\t{ rank=same; %s; } // layer %d\n", strings.Join(ids, "; "), j+1)
+		}
+
+		fmt.Fprintln(w, "\t}")
+	}
+
+	fmt.Fprintln(w, "}")
+}
+
+func renderMermaid(w io.Writer, views []*graphView) {
+	fmt.Fprintln(w, "flowchart TD")
+
+	ids := map[string]string{}
+	cyclic := []string{}
+	cyclicLinks := []string{}
+	links := 0
+
+	for i, view := range views {
+		id := func(key string) string {
+			return ids[view.prefix+"/"+key]
+		}
+
+		keys := append([]string{}, view.keys...)
+
+		for _, key := range keys {
+			ids[view.prefix+"/"+key] = fmt.Sprintf("n%d", len(ids))
+		}
+
+		// dependencies which are not defined are declared as nodes as well,
+		// like the dot and ascii formats show them.
+		for _, edge := range view.edges() {
+			if _, ok := ids[view.prefix+"/"+edge[0]]; !ok {
+				ids[view.prefix+"/"+edge[0]] = fmt.Sprintf("n%d", len(ids))
+				keys = append(keys, edge[0])
+			}
+		}
+
+		fmt.Fprintf(w, "\tsubgraph g%d [%q]\n", i, view.name)
+
+		for _, key := range keys {
+			fmt.Fprintf(w, "\t\t%s[%q]\n", id(key), key)
+
+			if view.cyclic[key] {
+				cyclic = append(cyclic, id(key))
+			}
+		}
+
+		for _, edge := range view.edges() {
+			fmt.Fprintf(w, "\t\t%s --> %s\n", id(edge[0]), id(edge[1]))
+
+			if view.cyclicEdges[edge] {
+				cyclicLinks = append(cyclicLinks, fmt.Sprint(links))
+			}
+
+			links++
+		}
+
+		for j, layer := range view.layers {
+			fmt.Fprintf(w, "\t\t%%%% layer %d: %s\n", j+1, strings.Join(layer, ", "))
+		}
+
+		fmt.Fprintln(w, "\tend")
+	}
+
+	if len(cyclic) > 0 {
+		fmt.Fprintln(w, "\tclassDef cycle stroke:#f00,color:#f00")
+		fmt.Fprintf(w, "\tclass %s cycle\n", strings.Join(cyclic, ","))
+	}
+
+	if len(cyclicLinks) > 0 {
+		fmt.Fprintf(w, "\tlinkStyle %s stroke:#f00\n", strings.Join(cyclicLinks, ","))
+	}
+}
+
+func renderASCII(w io.Writer, views []*graphView) {
+	for i, view := range views {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		if view.prefix == "action" {
+			fmt.Fprintln(w, "actions:")
+		} else {
+			fmt.Fprintf(w, "steps of %s:\n", view.name)
+		}
+
+		// the trees start at the keys nothing depends on and list their
+		// dependencies below them.
+		roots := []string{}
+
+		for _, key := range view.keys {
+			if len(view.graph.GetDependents(key)) == 0 {
+				roots = append(roots, key)
+			}
+		}
+
+		// keys of a cycle nothing outside of it depends on are not
+		// reachable from any root
+		reachable := map[string]bool{}
+
+		var reach func(key string)
+
+		reach = func(key string) {
+			if reachable[key] {
+				return
+			}
+
+			reachable[key] = true
+
+			for _, dep := range view.graph.GetDependencies(key) {
+				reach(dep)
+			}
+		}
+
+		for _, root := range roots {
+			reach(root)
+		}
+
+		for _, cycle := range view.cycles {
+			if !reachable[cycle[0]] {
+				roots = append(roots, cycle[0])
+				reach(cycle[0])
+			}
+		}
+
+		for _, root := range roots {
+			renderASCIITree(w, view, root, "  ", "  ", map[string]bool{})
+		}
+
+		if len(view.layers) > 0 {
+			fmt.Fprintln(w, "  layers:")
+
+			for j, layer := range view.layers {
+				fmt.Fprintf(w, "    %d. %s\n", j+1, strings.Join(layer, ", "))
+			}
+		}
+
+		if len(view.cycles) > 0 {
+			fmt.Fprintln(w, "  cycles:")
+
+			for _, cycle := range view.cycles {
+				fmt.Fprintf(w, "    %s\n", strings.Join(cycle, " -> "))
+			}
+		}
+	}
+}
+
+func renderASCIITree(w io.Writer, view *graphView, key string, prefix string, childPrefix string, path map[string]bool) {
+	if path[key] {
+		fmt.Fprintf(w, "%s%s (cycle)\n", prefix, key)
+
+		return
+	}
+
+	fmt.Fprintf(w, "%s%s\n", prefix, key)
+
+	path[key] = true
+	defer delete(path, key)
+
+	deps := append([]string{}, view.graph.GetDependencies(key)...)
+	sort.Strings(deps)
+
+	for i, dep := range deps {
+		if i == len(deps)-1 {
+			renderASCIITree(w, view, dep, childPrefix+"└── ", childPrefix+"    ", path)
+		} else {
+			renderASCIITree(w, view, dep, childPrefix+"├── ", childPrefix+"│   ", path)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderGraph(t *testing.T) {
+	list := &List{
+		Actions: map[string]*Action{
+			"build": {
+				Steps: map[string]*Step{
+					"a": {Type: "print"},
+					"b": {Type: "print", Dependencies: []string{"a", "c"}},
+					"c": {Type: "print", Dependencies: []string{"b"}},
+				},
+			},
+			"deploy": {Dependencies: []string{"build"}, Steps: map[string]*Step{"d": {Type: "print"}}},
+		},
+	}
+
+	var b bytes.Buffer
+
+	if err := RenderGraph(&b, list, []string{"deploy"}, GraphASCII); err != nil {
+		t.Fatalf("RenderGraph() error = %v", err)
+	}
+
+	want := `actions:
+  deploy
+  └── build
+  layers:
+    1. build
+    2. deploy
+
+steps of build:
+  b
+  ├── a
+  └── c
+      └── b (cycle)
+  layers:
+    1. a
+  cycles:
+    b -> c -> b
+
+steps of deploy:
+  d
+  layers:
+    1. d
+`
+
+	if got := b.String(); got != want {
+		t.Errorf("RenderGraph() got\n%s\nwant\n%s", got, want)
+	}
+
+	b.Reset()
+
+	if err := RenderGraph(&b, list, nil, GraphDot); err != nil {
+		t.Fatalf("RenderGraph() error = %v", err)
+	}
+
+	if !strings.Contains(b.String(), `"build/c" -> "build/b" [color=red];`) || !strings.Contains(b.String(), `"action/build" -> "action/deploy";`) {
+		t.Errorf("RenderGraph() got\n%s", b.String())
+	}
+
+	b.Reset()
+
+	list.Actions["deploy"].Steps["d"].Dependencies = []string{"missing"}
+
+	if err := RenderGraph(&b, list, []string{"deploy"}, GraphMermaid); err != nil {
+		t.Fatalf("RenderGraph() error = %v", err)
+	}
+
+	// the dangling dependency is declared like any other node
+	if !strings.Contains(b.String(), "n6[\"missing\"]") || !strings.Contains(b.String(), "n6 --> n5") {
+		t.Errorf("RenderGraph() got\n%s", b.String())
+	}
+}
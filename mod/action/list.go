@@ -24,6 +24,8 @@ package action
 
 import (
 	"errors"
+	"sort"
+	"strings"
 
 	"github.com/chapterjason/j3n/mod/topology"
 )
@@ -32,6 +34,9 @@ var ErrActionNotFound = errors.New("action not found")
 
 type List struct {
 	Actions map[string]*Action `json:"actions" yaml:"actions"`
+	// Templates are steps which are used by other steps, see
+	// List.ResolveTemplates.
+	Templates map[string]*Step `json:"templates,omitempty" yaml:"templates,omitempty"`
 }
 
 func (l *List) HasAction(actionName string) bool {
@@ -48,7 +53,40 @@ func (l *List) GetAction(actionName string) (*Action, error) {
 	return l.Actions[actionName], nil
 }
 
+// GetNames returns the names of all actions in alphabetical order.
+func (l *List) GetNames() []string {
+	names := []string{}
+
+	for name := range l.Actions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// GetGraph returns the graph of the actions, in which an action also
+// depends on the actions whose steps or outputs its steps reference.
 func (l *List) GetGraph() *topology.DependencyGraph {
+	graph := l.getDependencyGraph()
+
+	for _, actionName := range l.GetNames() {
+		for _, key := range l.getReferencedSteps(actionName) {
+			dep := key[:strings.Index(key, ".")]
+
+			if _, ok := graph.GetDependency(actionName, dep); !ok {
+				graph.AddEdge(actionName, dep)
+			}
+		}
+	}
+
+	return graph
+}
+
+// getDependencyGraph returns the graph of the configured dependencies of
+// the actions.
+func (l *List) getDependencyGraph() *topology.DependencyGraph {
 	graph := topology.NewDependencyGraph()
 
 	for actionName, action := range l.Actions {
@@ -0,0 +1,244 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Matrix expands a step into one variant per combination of its values,
+// like {"go": ["1.18", "1.19"], "db": ["postgres", "mysql"]}. The values of a
+// variant are available as placeholders like "{{matrix.go}}".
+type Matrix struct {
+	Values map[string][]any
+	// Include extends the combinations matching all of its matrix values
+	// with its other values, or adds a combination if none matches.
+	Include []map[string]any
+	// Exclude removes the combinations matching all of its values.
+	Exclude []map[string]any
+}
+
+func (m Matrix) MarshalJSON() ([]byte, error) {
+	values := map[string]any{}
+
+	for key, v := range m.Values {
+		values[key] = v
+	}
+
+	if len(m.Include) > 0 {
+		values["include"] = m.Include
+	}
+
+	if len(m.Exclude) > 0 {
+		values["exclude"] = m.Exclude
+	}
+
+	return json.Marshal(values)
+}
+
+func (m *Matrix) UnmarshalJSON(bytes []byte) error {
+	var raw map[string]json.RawMessage
+
+	if err := json.Unmarshal(bytes, &raw); err != nil {
+		return err
+	}
+
+	matrix := Matrix{Values: map[string][]any{}}
+
+	for key, value := range raw {
+		var err error
+
+		switch key {
+		case "include":
+			err = json.Unmarshal(value, &matrix.Include)
+		case "exclude":
+			err = json.Unmarshal(value, &matrix.Exclude)
+		default:
+			var values []any
+
+			err = json.Unmarshal(value, &values)
+			matrix.Values[key] = values
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "matrix %s", key)
+		}
+	}
+
+	*m = matrix
+
+	return nil
+}
+
+// Combinations returns the combinations of the matrix in a stable order.
+func (m *Matrix) Combinations() []map[string]any {
+	keys := []string{}
+
+	for key := range m.Values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	combinations := []map[string]any{}
+
+	if len(keys) > 0 {
+		combinations = append(combinations, map[string]any{})
+	}
+
+	for _, key := range keys {
+		next := []map[string]any{}
+
+		for _, combination := range combinations {
+			for _, value := range m.Values[key] {
+				c := map[string]any{key: value}
+
+				for k, v := range combination {
+					c[k] = v
+				}
+
+				next = append(next, c)
+			}
+		}
+
+		combinations = next
+	}
+
+	filtered := []map[string]any{}
+
+	for _, combination := range combinations {
+		excluded := false
+
+		for _, exclude := range m.Exclude {
+			if matchesCombination(combination, exclude) {
+				excluded = true
+
+				break
+			}
+		}
+
+		if !excluded {
+			filtered = append(filtered, combination)
+		}
+	}
+
+	for _, include := range m.Include {
+		// only the values of the matrix itself decide whether an include
+		// extends a combination.
+		matrixValues := map[string]any{}
+
+		for key, value := range include {
+			if _, ok := m.Values[key]; ok {
+				matrixValues[key] = value
+			}
+		}
+
+		extended := false
+
+		for _, combination := range filtered {
+			if !matchesCombination(combination, matrixValues) {
+				continue
+			}
+
+			for key, value := range include {
+				combination[key] = value
+			}
+
+			extended = true
+		}
+
+		if !extended {
+			combination := map[string]any{}
+
+			for key, value := range include {
+				combination[key] = value
+			}
+
+			filtered = append(filtered, combination)
+		}
+	}
+
+	return filtered
+}
+
+// matchesCombination returns whether the combination has all given values.
+func matchesCombination(combination map[string]any, values map[string]any) bool {
+	for key, value := range values {
+		if v, ok := combination[key]; !ok || !reflect.DeepEqual(v, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// expandMatrix returns one variant per combination of the matrix of the
+// step. The suffix of a variant are its values ordered by their keys.
+func expandMatrix(step *Step) ([]variant, error) {
+	combinations := step.Matrix.Combinations()
+
+	if len(combinations) == 0 {
+		return nil, errors.New("matrix has no combinations")
+	}
+
+	variants := []variant{}
+	suffixes := map[string]bool{}
+
+	for _, combination := range combinations {
+		keys := []string{}
+
+		for key := range combination {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		parts := []string{}
+		variables := map[string]any{}
+
+		for _, key := range keys {
+			parts = append(parts, FormatOutput(combination[key]))
+			variables["matrix."+key] = combination[key]
+		}
+
+		suffix := strings.Join(parts, "_")
+
+		if suffixes[suffix] {
+			return nil, errors.Errorf("matrix combination %s is not unique", suffix)
+		}
+
+		suffixes[suffix] = true
+
+		v := step.withVariables(variables)
+		v.Matrix = nil
+
+		variants = append(variants, variant{suffix: suffix, step: v})
+	}
+
+	return variants, nil
+}
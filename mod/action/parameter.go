@@ -0,0 +1,194 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnknownParameter     = errors.New("unknown parameter")
+	ErrMissingParameter     = errors.New("missing required parameter")
+	ErrInvalidParameter     = errors.New("invalid parameter value")
+	ErrTooManyArguments     = errors.New("too many arguments")
+	ErrUnknownParameterType = errors.New("unknown parameter type")
+)
+
+type ParameterType string
+
+const (
+	ParameterString ParameterType = "string"
+	ParameterInt    ParameterType = "int"
+	ParameterFloat  ParameterType = "float"
+	ParameterBool   ParameterType = "bool"
+)
+
+// Parameter is declared by an action and bound from the command line. Its
+// value is available as placeholder like "{{param.env}}" in the params of
+// the steps and as "param.env" in conditions.
+type Parameter struct {
+	Name        string        `json:"name" yaml:"name"`
+	Type        ParameterType `json:"type,omitempty" yaml:"type,omitempty"`
+	Description string        `json:"description,omitempty" yaml:"description,omitempty"`
+	Default     any           `json:"default,omitempty" yaml:"default,omitempty"`
+	Required    bool          `json:"required,omitempty" yaml:"required,omitempty"`
+	Enum        []any         `json:"enum,omitempty" yaml:"enum,omitempty"`
+}
+
+// Parse converts a value given on the command line to the type of the
+// parameter and checks it against the enum.
+func (p *Parameter) Parse(text string) (any, error) {
+	var value any
+	var err error
+
+	switch p.Type {
+	case "", ParameterString:
+		value = text
+	case ParameterInt:
+		value, err = strconv.Atoi(text)
+	case ParameterFloat:
+		value, err = strconv.ParseFloat(text, 64)
+	case ParameterBool:
+		value, err = strconv.ParseBool(text)
+	default:
+		return nil, errors.Wrapf(ErrUnknownParameterType, "parameter %s: %s", p.Name, p.Type)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidParameter, "parameter %s: %s is not of type %s", p.Name, text, p.Type)
+	}
+
+	return value, p.validate(value)
+}
+
+// validate checks the value against the enum of the parameter. Values from
+// the configuration are compared by their text, as numbers are decoded as
+// floats.
+func (p *Parameter) validate(value any) error {
+	if len(p.Enum) == 0 {
+		return nil
+	}
+
+	for _, allowed := range p.Enum {
+		if reflect.DeepEqual(allowed, value) || FormatOutput(allowed) == FormatOutput(value) {
+			return nil
+		}
+	}
+
+	allowed := []string{}
+
+	for _, v := range p.Enum {
+		allowed = append(allowed, FormatOutput(v))
+	}
+
+	return errors.Wrapf(ErrInvalidParameter, "parameter %s: %s is not one of %s", p.Name, FormatOutput(value), strings.Join(allowed, ", "))
+}
+
+// BindParameters returns the values of the parameters of the action. The
+// arguments are bound to the parameters in their order, the named values
+// like "env=staging" by their names. Parameters without a value get their
+// default.
+func (a *Action) BindParameters(args []string, named map[string]string) (map[string]any, error) {
+	if len(args) > len(a.Parameters) {
+		return nil, errors.Wrapf(ErrTooManyArguments, "expected at most %d", len(a.Parameters))
+	}
+
+	texts := map[string]string{}
+
+	for i, arg := range args {
+		texts[a.Parameters[i].Name] = arg
+	}
+
+	for name, text := range named {
+		if a.GetParameter(name) == nil {
+			return nil, errors.Wrap(ErrUnknownParameter, name)
+		}
+
+		if _, ok := texts[name]; ok {
+			return nil, fmt.Errorf("parameter %s is given twice", name)
+		}
+
+		texts[name] = text
+	}
+
+	values := map[string]any{}
+
+	for _, parameter := range a.Parameters {
+		text, ok := texts[parameter.Name]
+
+		switch {
+		case ok:
+			value, err := parameter.Parse(text)
+
+			if err != nil {
+				return nil, err
+			}
+
+			values[parameter.Name] = value
+		case parameter.Default != nil:
+			if err := parameter.validate(parameter.Default); err != nil {
+				return nil, err
+			}
+
+			values[parameter.Name] = parameter.Default
+		case parameter.Required:
+			return nil, errors.Wrap(ErrMissingParameter, parameter.Name)
+		default:
+			values[parameter.Name] = nil
+		}
+	}
+
+	return values, nil
+}
+
+func (a *Action) GetParameter(name string) *Parameter {
+	for _, parameter := range a.Parameters {
+		if parameter.Name == name {
+			return parameter
+		}
+	}
+
+	return nil
+}
+
+// ParseNamedParameters parses parameters like "env=staging".
+func ParseNamedParameters(values []string) (map[string]string, error) {
+	named := map[string]string{}
+
+	for _, value := range values {
+		name, text, ok := strings.Cut(value, "=")
+
+		if !ok || name == "" {
+			return nil, errors.Errorf("invalid parameter %s, expected name=value", value)
+		}
+
+		named[name] = text
+	}
+
+	return named, nil
+}
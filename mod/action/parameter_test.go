@@ -0,0 +1,72 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestAction_BindParameters(t *testing.T) {
+	action := &Action{
+		Parameters: []*Parameter{
+			{Name: "env", Required: true, Enum: []any{"staging", "production"}},
+			{Name: "replicas", Type: ParameterInt, Default: float64(2)},
+			{Name: "debug", Type: ParameterBool},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		named map[string]string
+		want  map[string]any
+		err   error
+	}{
+		{"positional", []string{"staging", "3"}, nil, map[string]any{"env": "staging", "replicas": 3, "debug": nil}, nil},
+		{"named", nil, map[string]string{"env": "production", "debug": "true"}, map[string]any{"env": "production", "replicas": float64(2), "debug": true}, nil},
+		{"missing", nil, nil, nil, ErrMissingParameter},
+		{"enum", []string{"dev"}, nil, nil, ErrInvalidParameter},
+		{"type", []string{"staging", "many"}, nil, nil, ErrInvalidParameter},
+		{"unknown", []string{"staging"}, map[string]string{"region": "eu"}, nil, ErrUnknownParameter},
+		{"too many", []string{"staging", "1", "true", "x"}, nil, nil, ErrTooManyArguments},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, err := action.BindParameters(tt.args, tt.named)
+
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("BindParameters() error = %v, want %v", err, tt.err)
+				}
+
+				if tt.err == nil && !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("BindParameters() got = %v, want %v", got, tt.want)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,240 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/chapterjason/j3n/mod/version"
+	"github.com/chapterjason/j3n/modx/gitx"
+)
+
+var (
+	ErrUnknownPlaceholder = errors.New("unknown placeholder")
+
+	// PlaceholderExpression matches placeholders like "{{VERSION}}",
+	// "{{ build.stdout }}" or "{{go:build.binary}}" and the escape "{{{{".
+	// Names start with a word character, so templates like
+	// "{{.ImportPath}}" are left unchanged.
+	PlaceholderExpression = regexp.MustCompile(`{{{{|{{\s*([-+]?\w[\w.\-:]*)\s*}}`)
+
+	// templateKeywords are the go template actions without arguments, like
+	// the "{{end}}" of "{{range .Deps}}{{.}}{{end}}", which are no
+	// placeholders.
+	templateKeywords = map[string]bool{"end": true, "else": true, "break": true, "continue": true, "nil": true}
+)
+
+// Variables returns the value of a placeholder by its name.
+type Variables func(name string) (any, error)
+
+type variablesKey struct{}
+
+func WithVariables(ctx context.Context, variables Variables) context.Context {
+	return context.WithValue(ctx, variablesKey{}, variables)
+}
+
+// GetVariables returns the variables of the running step, which default to
+// the builtin variables.
+func GetVariables(ctx context.Context) Variables {
+	if variables, ok := ctx.Value(variablesKey{}).(Variables); ok {
+		return variables
+	}
+
+	return BuiltinVariables
+}
+
+// ExpandPlaceholders replaces all placeholders like "{{VERSION}}" in the
+// text with their values, "{{{{" is replaced with a literal "{{". Go template
+// keywords like "{{end}}" are kept.
+func ExpandPlaceholders(text string, variables Variables) (string, error) {
+	var err error
+
+	expanded := PlaceholderExpression.ReplaceAllStringFunc(
+		text, func(placeholder string) string {
+			if err != nil {
+				return placeholder
+			}
+
+			if placeholder == "{{{{" {
+				return "{{"
+			}
+
+			name := PlaceholderExpression.FindStringSubmatch(placeholder)[1]
+
+			if templateKeywords[name] {
+				return placeholder
+			}
+
+			var v any
+
+			v, err = variables(name)
+
+			if err != nil {
+				err = errors.Wrapf(err, "placeholder %s", placeholder)
+
+				return placeholder
+			}
+
+			// like a command substitution in a shell, trailing newlines of
+			// outputs are removed
+			return strings.TrimRight(FormatOutput(v), "\r\n")
+		},
+	)
+
+	if err != nil {
+		return "", err
+	}
+
+	return expanded, nil
+}
+
+// ExpandParams returns a copy of the params in which the placeholders of all
+// strings are expanded, including the strings nested in lists and maps.
+func ExpandParams(params map[string]any, variables Variables) (map[string]any, error) {
+	if params == nil {
+		return nil, nil
+	}
+
+	expanded, err := expandValue(params, variables)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return expanded.(map[string]any), nil
+}
+
+func expandValue(value any, variables Variables) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return ExpandPlaceholders(v, variables)
+	case []any:
+		expanded := make([]any, len(v))
+
+		for i, item := range v {
+			e, err := expandValue(item, variables)
+
+			if err != nil {
+				return nil, err
+			}
+
+			expanded[i] = e
+		}
+
+		return expanded, nil
+	case []string:
+		expanded := make([]string, len(v))
+
+		for i, item := range v {
+			e, err := ExpandPlaceholders(item, variables)
+
+			if err != nil {
+				return nil, err
+			}
+
+			expanded[i] = e
+		}
+
+		return expanded, nil
+	case map[string]any:
+		expanded := make(map[string]any, len(v))
+
+		for key, item := range v {
+			e, err := expandValue(item, variables)
+
+			if err != nil {
+				return nil, err
+			}
+
+			expanded[key] = e
+		}
+
+		return expanded, nil
+	}
+
+	return value, nil
+}
+
+// BuiltinVariables resolves the placeholders known from version.Replace,
+// "GIT_BRANCH", "GIT_COMMIT", "GIT_COMMIT_SHORT" and environment variables
+// like "env.HOME".
+func BuiltinVariables(name string) (any, error) {
+	switch {
+	case strings.HasPrefix(name, "env."):
+		v, ok := os.LookupEnv(strings.TrimPrefix(name, "env."))
+
+		if !ok {
+			return nil, errors.Wrapf(ErrUnknownPlaceholder, "environment variable %s is not set", strings.TrimPrefix(name, "env."))
+		}
+
+		return v, nil
+	case name == "GIT_BRANCH":
+		branch, err := gitx.GetBranch()
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get git branch")
+		}
+
+		return branch, nil
+	case name == "GIT_COMMIT" || name == "GIT_COMMIT_SHORT":
+		commit, err := gitx.GetCommit()
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get git commit")
+		}
+
+		if name == "GIT_COMMIT_SHORT" && len(commit) > 7 {
+			commit = commit[:7]
+		}
+
+		return commit, nil
+	case strings.HasPrefix(name, "TIME_"):
+		return replaceVersion(name, version.Version{})
+	case strings.Contains(name, "VERSION"):
+		v, err := version.Get()
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get version")
+		}
+
+		return replaceVersion(name, v)
+	}
+
+	return nil, ErrUnknownPlaceholder
+}
+
+func replaceVersion(name string, v version.Version) (any, error) {
+	placeholder := "{{" + name + "}}"
+	replaced := version.Replace(placeholder, v)
+
+	if replaced == placeholder {
+		return nil, ErrUnknownPlaceholder
+	}
+
+	return replaced, nil
+}
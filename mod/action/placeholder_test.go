@@ -0,0 +1,87 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestExpandParams(t *testing.T) {
+	variables := func(name string) (any, error) {
+		switch name {
+		case "build.stdout":
+			return "out", nil
+		case "test.exit_code":
+			return 1, nil
+		}
+
+		return BuiltinVariables(name)
+	}
+
+	t.Setenv("J3N_TEST", "env")
+
+	params := map[string]any{
+		"command":  "go",
+		"args":     []any{"build", "{{ build.stdout }}", "-X main.code={{test.exit_code}}"},
+		"nested":   map[string]any{"env": []any{"A={{env.J3N_TEST}}"}},
+		"year":     "{{TIME_YEAR}}",
+		"number":   1.5,
+		"template": []any{"-f", "{{.ImportPath}} {{ .Dir }}", "{{{{end}}", "{{{{VERSION}}", "{{range .Deps}}{{.}}{{ end }}{{else}}"},
+	}
+
+	got, err := ExpandParams(params, variables)
+
+	if err != nil {
+		t.Fatalf("ExpandParams() error = %v", err)
+	}
+
+	want := map[string]any{
+		"command":  "go",
+		"args":     []any{"build", "out", "-X main.code=1"},
+		"nested":   map[string]any{"env": []any{"A=env"}},
+		"year":     fmt.Sprintf("%d", time.Now().Year()),
+		"number":   1.5,
+		"template": []any{"-f", "{{.ImportPath}} {{ .Dir }}", "{{end}}", "{{VERSION}}", "{{range .Deps}}{{.}}{{ end }}{{else}}"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandParams() got = %v, want %v", got, want)
+	}
+
+	if params["args"].([]any)[1] != "{{ build.stdout }}" {
+		t.Errorf("ExpandParams() modified the original params")
+	}
+
+	for _, text := range []string{"{{UNKNOWN}}", "{{env.J3N_TEST_UNSET}}", "{{TIME_UNKNOWN}}"} {
+		_, err = ExpandParams(map[string]any{"text": text}, variables)
+
+		if !errors.Is(err, ErrUnknownPlaceholder) {
+			t.Errorf("ExpandParams(%s) error = %v, want %v", text, err, ErrUnknownPlaceholder)
+		}
+	}
+}
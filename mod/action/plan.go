@@ -0,0 +1,199 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"os"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Plan is what the execution of an action would do, in the order of the
+// layers of the actions and their steps.
+type Plan struct {
+	Action string          `json:"action"`
+	Layers [][]*ActionPlan `json:"layers"`
+}
+
+type ActionPlan struct {
+	Name   string        `json:"name"`
+	If     string        `json:"if,omitempty"`
+	Unless string        `json:"unless,omitempty"`
+	Layers [][]*StepPlan `json:"layers"`
+}
+
+// StepPlan is a step with its expanded params and the command it would run,
+// if its type runs one.
+type StepPlan struct {
+	Name         string         `json:"name"`
+	Type         string         `json:"type"`
+	Dependencies []string       `json:"dependencies"`
+	If           string         `json:"if,omitempty"`
+	Unless       string         `json:"unless,omitempty"`
+	Input        string         `json:"input,omitempty"`
+	Params       map[string]any `json:"params,omitempty"`
+	Command      []string       `json:"command,omitempty"`
+	Directory    string         `json:"directory,omitempty"`
+	// Env are the environment variables set for the command, without the
+	// inherited environment.
+	Env []string `json:"env,omitempty"`
+	// Error is the reason why the command of the step is not known, like a
+	// missing param.
+	Error string `json:"error,omitempty"`
+}
+
+// Plan returns the plan of the execution of the action like Execute,
+// without running any step. Placeholders of outputs which are only known
+// after a step ran are kept, other unknown placeholders are reported as the
+// error of the step.
+func (e *Executer) Plan(actionName string) (*Plan, error) {
+	return e.PlanSelection(actionName, e.list.GetSelection(actionName))
+}
+
+// PlanSelection returns the plan of the execution of the selected steps
+// like ExecuteSelection.
+func (e *Executer) PlanSelection(actionName string, selection Selection) (*Plan, error) {
+	if _, err := e.list.GetAction(actionName); err != nil {
+		return nil, errors.Wrapf(err, "action %s", actionName)
+	}
+
+	adg, err := e.prepare(actionName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Action: actionName, Layers: [][]*ActionPlan{}}
+
+	for _, layer := range adg.GetLayers() {
+		actions := []*ActionPlan{}
+
+		for _, name := range layer {
+			if selection.IncludesAction(name) {
+				actions = append(actions, e.planAction(name, selection[name]))
+			}
+		}
+
+		if len(actions) > 0 {
+			plan.Layers = append(plan.Layers, actions)
+		}
+	}
+
+	return plan, nil
+}
+
+func (e *Executer) planAction(actionName string, selected map[string]bool) *ActionPlan {
+	action := e.list.Actions[actionName]
+
+	plan := &ActionPlan{
+		Name:   actionName,
+		If:     action.If,
+		Unless: action.Unless,
+		Layers: [][]*StepPlan{},
+	}
+
+	for _, layer := range action.GetGraph().GetLayers() {
+		steps := []*StepPlan{}
+
+		for _, stepName := range layer {
+			step, ok := action.Steps[stepName]
+
+			if !ok || (selected != nil && !selected[stepName]) {
+				continue
+			}
+
+			steps = append(steps, e.planStep(actionName, stepName, step))
+		}
+
+		if len(steps) > 0 {
+			plan.Layers = append(plan.Layers, steps)
+		}
+	}
+
+	return plan
+}
+
+func (e *Executer) planStep(actionName string, stepName string, step *Step) *StepPlan {
+	plan := &StepPlan{
+		Name:         stepName,
+		Type:         step.Type,
+		Dependencies: append([]string{}, step.Dependencies...),
+		If:           step.If,
+		Unless:       step.Unless,
+		Input:        step.Input,
+	}
+
+	variables := e.getVariables(actionName, step)
+
+	params, err := ExpandParams(
+		step.Params, func(name string) (any, error) {
+			v, err := variables(name)
+
+			// outputs of steps are only known after the steps ran, so their
+			// placeholders are kept.
+			if err != nil && len(e.list.getInputProviders(actionName, name)) > 0 {
+				return "{{" + name + "}}", nil
+			}
+
+			return v, err
+		},
+	)
+
+	if err != nil {
+		plan.Error = err.Error()
+
+		return plan
+	}
+
+	plan.Params = params
+
+	command, ok := Commands[step.Type]
+
+	if !ok {
+		return plan
+	}
+
+	cmd, err := command(params)
+
+	if err != nil {
+		plan.Error = err.Error()
+
+		return plan
+	}
+
+	plan.Command = cmd.Args
+	plan.Directory = cmd.Dir
+	plan.Env = cmd.Env
+
+	if plan.Directory == "" {
+		plan.Directory, _ = os.Getwd()
+	}
+
+	// commands extending the environment only show the variables they add.
+	if environ := os.Environ(); len(cmd.Env) >= len(environ) && reflect.DeepEqual(cmd.Env[:len(environ)], environ) {
+		plan.Env = cmd.Env[len(environ):]
+	}
+
+	return plan
+}
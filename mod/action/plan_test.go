@@ -0,0 +1,101 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestExecuter_Plan(t *testing.T) {
+	list := &List{
+		Actions: map[string]*Action{
+			"build": {
+				Steps: map[string]*Step{
+					"compile": {Type: "test.outputs", Params: map[string]any{"fail": true}},
+				},
+			},
+			"deploy": {
+				Dependencies: []string{"build"},
+				Parameters:   []*Parameter{{Name: "env", Default: "staging"}},
+				Steps: map[string]*Step{
+					"upload": {
+						Type:   "exec",
+						Params: map[string]any{"command": "upload", "args": []any{"{{param.env}}", "{{build.compile.output}}"}, "env": []any{"A=1"}},
+					},
+					"notify": {Type: "exec", Dependencies: []string{"upload"}, Params: map[string]any{}},
+					"tag":    {Type: "exec", Dependencies: []string{"upload"}, Params: map[string]any{"command": "git", "args": []any{"{{param.unknown}}"}}},
+				},
+			},
+		},
+	}
+
+	plan, err := NewExecuter(list, Options{}).Plan("deploy")
+
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	wd, _ := os.Getwd()
+
+	want := &Plan{
+		Action: "deploy",
+		Layers: [][]*ActionPlan{
+			{
+				{
+					Name: "build",
+					Layers: [][]*StepPlan{
+						{{Name: "compile", Type: "test.outputs", Dependencies: []string{}, Params: map[string]any{"fail": true}}},
+					},
+				},
+			},
+			{
+				{
+					Name: "deploy",
+					Layers: [][]*StepPlan{
+						{
+							{
+								Name:         "upload",
+								Type:         "exec",
+								Dependencies: []string{},
+								Params:       map[string]any{"command": "upload", "args": []any{"staging", "{{build.compile.output}}"}, "env": []any{"A=1"}},
+								Command:      []string{"upload", "staging", "{{build.compile.output}}"},
+								Directory:    wd,
+								Env:          []string{"A=1"},
+							},
+						},
+						{
+							{Name: "notify", Type: "exec", Dependencies: []string{"upload"}, Params: map[string]any{}, Error: "command is required"},
+							{Name: "tag", Type: "exec", Dependencies: []string{"upload"}, Error: "placeholder {{param.unknown}}: unknown placeholder"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("Plan() got = %+v, want %+v", plan, want)
+	}
+}
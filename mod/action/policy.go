@@ -0,0 +1,75 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+var ErrUnknownPolicy = errors.New("unknown policy")
+
+// Policy decides how the executer continues once a step failed.
+type Policy string
+
+const (
+	// PolicyFailFast cancels all running steps and starts no new ones.
+	PolicyFailFast Policy = "fail_fast"
+	// PolicyFinishLayer lets the running steps finish and starts no new ones.
+	PolicyFinishLayer Policy = "finish_layer"
+	// PolicyKeepGoing only skips the steps which depend on the failed one.
+	PolicyKeepGoing Policy = "keep_going"
+
+	DefaultPolicy = PolicyFinishLayer
+)
+
+var Policies = []Policy{PolicyFailFast, PolicyFinishLayer, PolicyKeepGoing}
+
+func ParsePolicy(s string) (Policy, error) {
+	for _, p := range Policies {
+		if string(p) == s {
+			return p, nil
+		}
+	}
+
+	return "", errors.Wrap(ErrUnknownPolicy, s)
+}
+
+func (p *Policy) UnmarshalJSON(bytes []byte) error {
+	var s string
+
+	if err := json.Unmarshal(bytes, &s); err != nil {
+		return err
+	}
+
+	parsed, err := ParsePolicy(s)
+
+	if err != nil {
+		return err
+	}
+
+	*p = parsed
+
+	return nil
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var ErrUnknownOutputMode = errors.New("unknown output mode")
+
+// OutputMode decides how the output of a running step is printed.
+type OutputMode string
+
+const (
+	// OutputStream prints every line as soon as it is written.
+	OutputStream OutputMode = "stream"
+	// OutputGrouped prints the whole output at once when the step finished.
+	OutputGrouped OutputMode = "grouped"
+	// OutputQuiet does not print any output.
+	OutputQuiet OutputMode = "quiet"
+
+	DefaultOutputMode = OutputGrouped
+)
+
+var OutputModes = []OutputMode{OutputStream, OutputGrouped, OutputQuiet}
+
+var colors = []int{31, 32, 33, 34, 35, 36}
+
+func ParseOutputMode(s string) (OutputMode, error) {
+	for _, m := range OutputModes {
+		if string(m) == s {
+			return m, nil
+		}
+	}
+
+	return "", errors.Wrap(ErrUnknownOutputMode, s)
+}
+
+// Printer writes the output of concurrently running steps line by line, so
+// that the lines of different steps are never mixed up.
+type Printer struct {
+	mutex sync.Mutex
+	out   io.Writer
+	color bool
+}
+
+func NewPrinter(out io.Writer, color bool) *Printer {
+	return &Printer{
+		out:   out,
+		color: color,
+	}
+}
+
+// Print writes all lines at once, each of them prefixed.
+func (p *Printer) Print(prefix string, b []byte) {
+	if len(b) == 0 {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, line := range strings.SplitAfter(strings.TrimSuffix(string(b), "\n"), "\n") {
+		p.writeLine(prefix, line)
+	}
+}
+
+// Writer returns a writer which prints every complete line prefixed. The
+// writer has to be flushed to print a trailing incomplete line.
+func (p *Printer) Writer(prefix string) *PrefixWriter {
+	return &PrefixWriter{
+		printer: p,
+		prefix:  prefix,
+	}
+}
+
+func (p *Printer) writeLine(prefix string, line string) {
+	line = strings.TrimSuffix(line, "\n")
+
+	if prefix == "" {
+		fmt.Fprintln(p.out, line)
+
+		return
+	}
+
+	if p.color {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(prefix))
+
+		prefix = fmt.Sprintf("\033[%dm%s\033[0m", colors[h.Sum32()%uint32(len(colors))], prefix)
+	}
+
+	fmt.Fprintf(p.out, "%s | %s\n", prefix, line)
+}
+
+type PrefixWriter struct {
+	printer *Printer
+	prefix  string
+	buffer  bytes.Buffer
+}
+
+func (w *PrefixWriter) Write(b []byte) (int, error) {
+	w.buffer.Write(b)
+
+	for {
+		i := bytes.IndexByte(w.buffer.Bytes(), '\n')
+
+		if i < 0 {
+			break
+		}
+
+		line := w.buffer.Next(i + 1)
+
+		w.printer.mutex.Lock()
+		w.printer.writeLine(w.prefix, string(line))
+		w.printer.mutex.Unlock()
+	}
+
+	return len(b), nil
+}
+
+func (w *PrefixWriter) Flush() {
+	if w.buffer.Len() == 0 {
+		return
+	}
+
+	w.printer.mutex.Lock()
+	w.printer.writeLine(w.prefix, w.buffer.String())
+	w.printer.mutex.Unlock()
+
+	w.buffer.Reset()
+}
+
+// StepOutput tells a step runner how to print its output.
+type StepOutput struct {
+	Mode    OutputMode
+	Prefix  string
+	Printer *Printer
+}
+
+type stepOutputKey struct{}
+
+func WithStepOutput(ctx context.Context, output *StepOutput) context.Context {
+	return context.WithValue(ctx, stepOutputKey{}, output)
+}
+
+// GetStepOutput returns the output settings of the running step, which
+// defaults to grouped output on stdout.
+func GetStepOutput(ctx context.Context) *StepOutput {
+	if output, ok := ctx.Value(stepOutputKey{}).(*StepOutput); ok {
+		return output
+	}
+
+	return &StepOutput{
+		Mode:    DefaultOutputMode,
+		Printer: NewPrinter(os.Stdout, false),
+	}
+}
+
+func (m *OutputMode) UnmarshalJSON(bytes []byte) error {
+	var s string
+
+	if err := json.Unmarshal(bytes, &s); err != nil {
+		return err
+	}
+
+	parsed, err := ParseOutputMode(s)
+
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+
+	return nil
+}
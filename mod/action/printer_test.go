@@ -0,0 +1,51 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrinter(t *testing.T) {
+	var out bytes.Buffer
+
+	p := NewPrinter(&out, false)
+	a := p.Writer("test/a")
+	b := p.Writer("test/b")
+
+	// incomplete lines are buffered until they are complete or flushed
+	_, _ = a.Write([]byte("a1\na"))
+	_, _ = b.Write([]byte("b1\n"))
+	_, _ = a.Write([]byte("2\n"))
+	_, _ = b.Write([]byte("b2"))
+	p.Print("test/c", []byte("c1\nc2\n"))
+	b.Flush()
+	a.Flush()
+
+	want := "test/a | a1\ntest/b | b1\ntest/a | a2\ntest/c | c1\ntest/c | c2\ntest/b | b2\n"
+
+	if out.String() != want {
+		t.Errorf("Printer got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
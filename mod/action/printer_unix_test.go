@@ -0,0 +1,112 @@
+//go:build !windows
+
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestStepOutput_Modes(t *testing.T) {
+	scripts := map[string]string{
+		"test/a": "echo a1; sleep 0.1; echo a2; sleep 0.1; echo a3",
+		"test/b": "sleep 0.05; echo b1; sleep 0.1; echo b2; sleep 0.1; echo b3",
+	}
+
+	tests := map[OutputMode]func(t *testing.T, lines []string){
+		OutputStream: func(t *testing.T, lines []string) {
+			if len(lines) != 6 {
+				t.Fatalf("got %d lines, want 6", len(lines))
+			}
+
+			// the lines are printed as they are written, so the steps
+			// alternate.
+			if lines[0][:6] == lines[1][:6] {
+				t.Errorf("got lines %q, want interleaved lines", lines)
+			}
+		},
+		OutputGrouped: func(t *testing.T, lines []string) {
+			if len(lines) != 6 {
+				t.Fatalf("got %d lines, want 6", len(lines))
+			}
+
+			// the lines of a step are printed together when it finished
+			for i := 1; i < 3; i++ {
+				if lines[i][:6] != lines[0][:6] || lines[i+3][:6] != lines[3][:6] {
+					t.Errorf("got lines %q, want the lines of each step together", lines)
+				}
+			}
+		},
+		OutputQuiet: func(t *testing.T, lines []string) {
+			if len(lines) != 0 {
+				t.Errorf("got lines %q, want none", lines)
+			}
+		},
+	}
+
+	for mode, check := range tests {
+		t.Run(string(mode), func(t *testing.T) {
+			var out bytes.Buffer
+			var wg sync.WaitGroup
+
+			printer := NewPrinter(&out, false)
+
+			for prefix, script := range scripts {
+				wg.Add(1)
+
+				go func(prefix string, script string) {
+					defer wg.Done()
+
+					ctx := WithStepOutput(context.Background(), &StepOutput{Mode: mode, Prefix: prefix, Printer: printer})
+
+					if _, err := Steps["shell"](ctx, nil, map[string]any{"script": script, "print_stdout": true}); err != nil {
+						t.Errorf("shell error = %v", err)
+					}
+				}(prefix, script)
+			}
+
+			wg.Wait()
+
+			lines := []string{}
+
+			for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+				if line == "" {
+					continue
+				}
+
+				if !strings.HasPrefix(line, "test/a | a") && !strings.HasPrefix(line, "test/b | b") {
+					t.Errorf("got line %q, want a prefixed line", line)
+				}
+
+				lines = append(lines, line)
+			}
+
+			check(t, lines)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"strings"
+
+	"github.com/chapterjason/j3n/mod/topology"
+	"github.com/chapterjason/j3n/modx/slicex"
+)
+
+// splitReference splits a qualified reference like "build.compile" into the
+// name of the action and the rest.
+func splitReference(reference string) (string, string, bool) {
+	i := strings.Index(reference, ".")
+
+	if i <= 0 {
+		return "", reference, false
+	}
+
+	return reference[:i], reference[i+1:], true
+}
+
+// GetStepGraph returns the graph of the steps of all actions with keys like
+// "build.compile". The steps of an action depend on all steps of the actions
+// it depends on and on the steps of other actions they reference.
+func (l *List) GetStepGraph() *topology.DependencyGraph {
+	graph := topology.NewDependencyGraph()
+
+	for _, actionName := range l.GetNames() {
+		action := l.Actions[actionName]
+
+		for _, stepName := range action.GetStepNames() {
+			key := actionName + "." + stepName
+			step := action.Steps[stepName]
+
+			graph.AddNode(key)
+
+			deps := []string{}
+
+			for _, dep := range step.Dependencies {
+				if !strings.Contains(dep, ".") {
+					deps = append(deps, actionName+"."+dep)
+				}
+			}
+
+			deps = append(deps, l.getStepReferences(actionName, step)...)
+
+			for _, dep := range action.Dependencies {
+				if a, ok := l.Actions[dep]; ok {
+					for _, name := range a.GetStepNames() {
+						deps = append(deps, dep+"."+name)
+					}
+				}
+			}
+
+			for _, dep := range deps {
+				if _, ok := graph.GetDependency(key, dep); !ok {
+					graph.AddEdge(key, dep)
+				}
+			}
+		}
+	}
+
+	return graph
+}
+
+// GetSelection returns the steps which have to run for the action, which
+// are all of its steps and the steps of other actions they need.
+func (l *List) GetSelection(actionName string) Selection {
+	selection := Selection{}
+	action, ok := l.Actions[actionName]
+
+	if !ok {
+		return selection
+	}
+
+	graph := l.GetStepGraph()
+	needed := topology.NewDependencyGraph()
+
+	for _, stepName := range action.GetStepNames() {
+		needed.Add(graph, actionName+"."+stepName)
+	}
+
+	for _, key := range needed.GetKeys() {
+		name, stepName, _ := splitReference(key)
+
+		selection.Add(name, stepName)
+	}
+
+	return selection
+}
+
+// getReferencedSteps returns the keys of the steps of other actions which
+// are referenced by the steps of the action.
+func (l *List) getReferencedSteps(actionName string) []string {
+	keys := []string{}
+	action := l.Actions[actionName]
+
+	for _, stepName := range action.GetStepNames() {
+		for _, key := range l.getStepReferences(actionName, action.Steps[stepName]) {
+			if !slicex.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	return keys
+}
+
+// getStepReferences returns the keys of the steps of other actions which
+// are referenced in the dependencies or the input of the step.
+func (l *List) getStepReferences(actionName string, step *Step) []string {
+	keys := []string{}
+
+	for _, dep := range step.Dependencies {
+		keys = append(keys, l.resolveStepReference(actionName, dep)...)
+	}
+
+	if step.Input != "" {
+		keys = append(keys, l.getInputReferences(actionName, step.Input)...)
+	}
+
+	return keys
+}
+
+// getInputReferences returns the keys of the steps of other actions an
+// input like "build.compile.stdout" references. Outputs of steps of the
+// same action like "compile.stdout" take precedence.
+func (l *List) getInputReferences(actionName string, input string) []string {
+	stepName, _, ok := splitReference(input)
+
+	if !ok {
+		return nil
+	}
+
+	if _, ok := l.Actions[actionName].Steps[stepName]; ok {
+		return nil
+	}
+
+	return l.resolveStepReference(actionName, input)
+}
+
+// resolveStepReference returns the keys of the steps of another action a
+// reference like "build.compile", "build.compile.stdout" or "build.binary"
+// needs. An output of an action needs all of its steps.
+func (l *List) resolveStepReference(actionName string, reference string) []string {
+	name, rest, ok := splitReference(reference)
+
+	if !ok || name == actionName {
+		return nil
+	}
+
+	action, ok := l.Actions[name]
+
+	if !ok {
+		return nil
+	}
+
+	if _, ok := action.Steps[rest]; ok {
+		return []string{reference}
+	}
+
+	if i := strings.LastIndex(rest, "."); i > 0 {
+		if _, ok := action.Steps[rest[:i]]; ok {
+			return []string{name + "." + rest[:i]}
+		}
+	}
+
+	if _, ok := action.Outputs[rest]; ok {
+		keys := []string{}
+
+		for _, stepName := range action.GetStepNames() {
+			keys = append(keys, name+"."+stepName)
+		}
+
+		return keys
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusSkipped   Status = "skipped"
+	StatusCancelled Status = "cancelled"
+)
+
+type Result struct {
+	Action   string
+	Step     string
+	Status   Status
+	Error    error
+	Duration time.Duration
+}
+
+// Report collects the results of all steps of an execution.
+type Report struct {
+	mutex   sync.Mutex
+	results []*Result
+}
+
+func NewReport() *Report {
+	return &Report{
+		results: []*Result{},
+	}
+}
+
+func (r *Report) Add(results ...*Result) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.results = append(r.results, results...)
+}
+
+func (r *Report) Results() []*Result {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]*Result{}, r.results...)
+}
+
+// Succeeded returns whether no step failed or was cancelled, steps skipped
+// because of their conditions do not count.
+func (r *Report) Succeeded() bool {
+	for _, result := range r.Results() {
+		if result.Status == StatusFailed || result.Status == StatusCancelled {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *Report) Count(status Status) int {
+	count := 0
+
+	for _, result := range r.Results() {
+		if result.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
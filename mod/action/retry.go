@@ -0,0 +1,154 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Retry configures how often a failing step is attempted again.
+type Retry struct {
+	// Attempts is the total number of attempts including the first one.
+	Attempts int `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+	// Delay is the time to wait before the second attempt.
+	Delay Duration `json:"delay,omitempty" yaml:"delay,omitempty"`
+	// Backoff multiplies the delay after every attempt, defaults to 1.
+	Backoff float64 `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	// RetryOnExitCodes and RetryOnOutputRegex restrict the failures which are
+	// retried, if neither is given every failure is retried.
+	RetryOnExitCodes   []int  `json:"retry_on_exit_codes,omitempty" yaml:"retry_on_exit_codes,omitempty"`
+	RetryOnOutputRegex string `json:"retry_on_output_regex,omitempty" yaml:"retry_on_output_regex,omitempty"`
+}
+
+// ExitError is returned by steps whose command exited with an unexpected
+// exit code.
+type ExitError struct {
+	Command  string
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+func (e *ExitError) Error() string {
+	message := fmt.Sprintf("command \"%s\" failed\n", e.Command)
+	message += fmt.Sprintf("    exit code: %d\n", e.ExitCode)
+	message += fmt.Sprintf("    stderr: %s\n", strings.TrimSpace(e.Stderr))
+	message += fmt.Sprintf("    stdout: %s\n", strings.TrimSpace(e.Stdout))
+
+	return message
+}
+
+// retry calls run until it succeeds, the attempts are exhausted or the error
+// is not retryable.
+func (e *Executer) retry(ctx context.Context, name string, retry *Retry, run func() (any, error)) (any, error) {
+	if retry == nil || retry.Attempts <= 1 {
+		return run()
+	}
+
+	var expression *regexp.Regexp
+
+	if retry.RetryOnOutputRegex != "" {
+		var err error
+
+		expression, err = regexp.Compile(retry.RetryOnOutputRegex)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid retry_on_output_regex")
+		}
+	}
+
+	delay := time.Duration(retry.Delay)
+	codes := []string{}
+
+	for attempt := 1; ; attempt++ {
+		out, err := run()
+
+		if err == nil {
+			return out, nil
+		}
+
+		var exitErr *ExitError
+
+		if errors.As(err, &exitErr) {
+			codes = append(codes, strconv.Itoa(exitErr.ExitCode))
+		} else {
+			codes = append(codes, "-")
+		}
+
+		if ctx.Err() != nil || errors.Is(err, ErrCancelled) {
+			return nil, err
+		}
+
+		if attempt >= retry.Attempts || !retry.retryable(err, expression) {
+			if attempt == 1 {
+				return nil, err
+			}
+
+			return nil, errors.Wrapf(err, "failed after %d attempts (exit codes: %s)", attempt, strings.Join(codes, ", "))
+		}
+
+		log.Warnf("step %s failed on attempt %d/%d, retrying in %s: %s", name, attempt, retry.Attempts, delay, strings.TrimSpace(err.Error()))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, contextError(ctx, err)
+		}
+
+		if retry.Backoff > 0 {
+			delay = time.Duration(float64(delay) * retry.Backoff)
+		}
+	}
+}
+
+func (r *Retry) retryable(err error, expression *regexp.Regexp) bool {
+	if len(r.RetryOnExitCodes) == 0 && expression == nil {
+		return true
+	}
+
+	var exitErr *ExitError
+
+	if errors.As(err, &exitErr) {
+		for _, code := range r.RetryOnExitCodes {
+			if code == exitErr.ExitCode {
+				return true
+			}
+		}
+
+		if expression != nil {
+			return expression.MatchString(exitErr.Stdout) || expression.MatchString(exitErr.Stderr)
+		}
+
+		return false
+	}
+
+	return expression != nil && expression.MatchString(err.Error())
+}
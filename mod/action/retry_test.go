@@ -0,0 +1,89 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestExecuter_retry(t *testing.T) {
+	tests := []struct {
+		name     string
+		retry    *Retry
+		codes    []int
+		attempts int
+		err      string
+	}{
+		{"no retry", nil, []int{1, 0}, 1, "exit code: 1"},
+		{"succeeds", &Retry{Attempts: 3}, []int{1, 2, 0}, 3, ""},
+		{"exhausted", &Retry{Attempts: 2}, []int{1, 2, 0}, 2, "failed after 2 attempts (exit codes: 1, 2)"},
+		{"exit codes", &Retry{Attempts: 3, RetryOnExitCodes: []int{1}}, []int{1, 2, 0}, 2, "failed after 2 attempts (exit codes: 1, 2)"},
+		{"output", &Retry{Attempts: 3, RetryOnOutputRegex: "connection (refused|reset)"}, []int{1, 0}, 2, ""},
+		{"output mismatch", &Retry{Attempts: 3, RetryOnOutputRegex: "timeout"}, []int{1, 0}, 1, "exit code: 1"},
+	}
+
+	e := NewExecuter(&List{}, Options{})
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				attempts := 0
+
+				_, err := e.retry(
+					context.Background(), "test/step", tt.retry, func() (any, error) {
+						code := tt.codes[attempts]
+						attempts++
+
+						if code == 0 {
+							return nil, nil
+						}
+
+						return nil, &ExitError{Command: "test", ExitCode: code, Stderr: "connection refused"}
+					},
+				)
+
+				if attempts != tt.attempts {
+					t.Errorf("retry() attempts = %d, want %d", attempts, tt.attempts)
+				}
+
+				if tt.err == "" && err != nil {
+					t.Errorf("retry() error = %v", err)
+				}
+
+				if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+					t.Errorf("retry() error = %v, want %s", err, tt.err)
+				}
+
+				var exitErr *ExitError
+
+				if err != nil && !errors.As(err, &exitErr) {
+					t.Errorf("retry() error = %v, want ExitError", err)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,213 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/chapterjason/j3n/mod/topology"
+)
+
+// scheduler runs the nodes of a dependency graph as soon as all of their
+// dependencies succeeded, instead of waiting for whole layers.
+type scheduler struct {
+	graph  *topology.DependencyGraph
+	keys   []string
+	policy Policy
+	// rule decides for a key what happens when one of its dependencies has
+	// been skipped, nil means SkipRuleRun.
+	rule func(key string) SkipRule
+}
+
+type scheduled struct {
+	key    string
+	status Status
+}
+
+// newScheduler creates a scheduler for the given keys of the graph.
+// Dependencies on keys which are not scheduled count as succeeded.
+func newScheduler(graph *topology.DependencyGraph, keys []string, policy Policy) *scheduler {
+	keys = append([]string{}, keys...)
+	sort.Strings(keys)
+
+	return &scheduler{
+		graph:  graph,
+		keys:   keys,
+		policy: policy,
+	}
+}
+
+// run calls execute for every key once its dependencies succeeded, and skip
+// for every key which is not executed.
+func (s *scheduler) run(
+	ctx context.Context,
+	execute func(ctx context.Context, key string) Status,
+	skip func(key string, status Status, reason error),
+) {
+	// the parent context tells whether the execution was cancelled from the
+	// outside, as opposed to being cancelled by the fail fast policy.
+	parent := ctx
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	included := map[string]bool{}
+
+	for _, key := range s.keys {
+		included[key] = true
+	}
+
+	remaining := map[string]int{}
+	ready := []string{}
+
+	for _, key := range s.keys {
+		for _, dep := range s.graph.GetDependencies(key) {
+			if included[dep] {
+				remaining[key]++
+			}
+		}
+
+		if remaining[key] == 0 {
+			ready = append(ready, key)
+		}
+	}
+
+	finished := map[string]bool{}
+	done := make(chan scheduled)
+	running := 0
+	stop := false
+
+	for {
+		for !stop && ctx.Err() == nil && len(ready) > 0 {
+			key := ready[0]
+			ready = ready[1:]
+			running++
+
+			go func(key string) {
+				done <- scheduled{key: key, status: execute(ctx, key)}
+			}(key)
+		}
+
+		if running == 0 {
+			break
+		}
+
+		result := <-done
+		running--
+		finished[result.key] = true
+
+		if result.status == StatusSkipped {
+			ready = s.release(result.key, true, included, finished, remaining, ready, skip)
+
+			continue
+		}
+
+		if result.status != StatusSucceeded {
+			switch s.policy {
+			case PolicyFailFast:
+				cancel()
+				stop = true
+			case PolicyFinishLayer:
+				stop = true
+			}
+
+			s.skipDependents(result.key, included, finished, skip)
+
+			continue
+		}
+
+		ready = s.release(result.key, false, included, finished, remaining, ready, skip)
+	}
+
+	for _, key := range s.keys {
+		if finished[key] {
+			continue
+		}
+
+		if parent.Err() != nil {
+			skip(key, StatusCancelled, ErrCancelled)
+		} else {
+			skip(key, StatusSkipped, errors.Wrapf(ErrStopped, "policy %s", s.policy))
+		}
+	}
+}
+
+// release marks the key as done for its dependents and returns the keys which
+// became ready. If the key was skipped, dependents following SkipRuleSkip are
+// skipped as well.
+func (s *scheduler) release(
+	key string,
+	skipped bool,
+	included map[string]bool,
+	finished map[string]bool,
+	remaining map[string]int,
+	ready []string,
+	skip func(key string, status Status, reason error),
+) []string {
+	dependents := s.graph.GetDependents(key)
+	sort.Strings(dependents)
+
+	for _, dependent := range dependents {
+		if !included[dependent] || finished[dependent] {
+			continue
+		}
+
+		if skipped && s.rule != nil && s.rule(dependent) == SkipRuleSkip {
+			finished[dependent] = true
+			skip(dependent, StatusSkipped, ErrDependencySkipped)
+
+			ready = s.release(dependent, true, included, finished, remaining, ready, skip)
+
+			continue
+		}
+
+		remaining[dependent]--
+
+		if remaining[dependent] == 0 {
+			ready = append(ready, dependent)
+		}
+	}
+
+	return ready
+}
+
+// skipDependents skips everything which directly or indirectly depends on the
+// key.
+func (s *scheduler) skipDependents(key string, included map[string]bool, finished map[string]bool, skip func(key string, status Status, reason error)) {
+	dependents := s.graph.GetDependents(key)
+	sort.Strings(dependents)
+
+	for _, dependent := range dependents {
+		if !included[dependent] || finished[dependent] {
+			continue
+		}
+
+		finished[dependent] = true
+		skip(dependent, StatusSkipped, ErrDependencyFailed)
+
+		s.skipDependents(dependent, included, finished, skip)
+	}
+}
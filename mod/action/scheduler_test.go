@@ -0,0 +1,172 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tracker records how many steps are running at the same time and when
+// they started and finished.
+type tracker struct {
+	mutex    sync.Mutex
+	running  int
+	max      int
+	started  map[string]time.Time
+	finished map[string]time.Time
+}
+
+var trackers sync.Map
+
+func init() {
+	MustRegister(
+		"test.track", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			value, _ := trackers.Load(params["tracker"])
+			tr := value.(*tracker)
+			name := params["name"].(string)
+
+			tr.mutex.Lock()
+			tr.running++
+			tr.started[name] = time.Now()
+
+			if tr.running > tr.max {
+				tr.max = tr.running
+			}
+
+			tr.mutex.Unlock()
+
+			time.Sleep(time.Duration(params["sleep"].(int)) * time.Millisecond)
+
+			tr.mutex.Lock()
+			tr.running--
+			tr.finished[name] = time.Now()
+			tr.mutex.Unlock()
+
+			return nil, nil
+		},
+	)
+}
+
+func newTracker(t *testing.T) (*tracker, func(name string, sleep int) *Step) {
+	tr := &tracker{started: map[string]time.Time{}, finished: map[string]time.Time{}}
+
+	trackers.Store(t.Name(), tr)
+	t.Cleanup(func() { trackers.Delete(t.Name()) })
+
+	return tr, func(name string, sleep int) *Step {
+		return &Step{Type: "test.track", Params: map[string]any{"tracker": t.Name(), "name": name, "sleep": sleep}}
+	}
+}
+
+func executeTracked(t *testing.T, action *Action, options Options) {
+	list := &List{Actions: map[string]*Action{"test": action}}
+
+	report, err := NewExecuter(list, options).Execute(context.Background(), "test")
+
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if !report.Succeeded() {
+		t.Fatalf("Execute() got results %v", report.Results())
+	}
+}
+
+func TestExecuter_Execute_Jobs(t *testing.T) {
+	tr, step := newTracker(t)
+	steps := map[string]*Step{}
+
+	for i := 0; i < 8; i++ {
+		name := fmt.Sprintf("s%d", i)
+		steps[name] = step(name, 50)
+	}
+
+	executeTracked(t, &Action{Steps: steps}, Options{Jobs: 2})
+
+	if tr.max != 2 {
+		t.Errorf("Execute() ran %d steps at once, want 2", tr.max)
+	}
+}
+
+func TestExecuter_Execute_MaxParallel(t *testing.T) {
+	tr, step := newTracker(t)
+	steps := map[string]*Step{}
+
+	for i := 0; i < 8; i++ {
+		name := fmt.Sprintf("s%d", i)
+		steps[name] = step(name, 50)
+	}
+
+	executeTracked(t, &Action{MaxParallel: 3, Steps: steps}, Options{Jobs: 8})
+
+	if tr.max != 3 {
+		t.Errorf("Execute() ran %d steps at once, want 3", tr.max)
+	}
+}
+
+func TestExecuter_Execute_Mutex(t *testing.T) {
+	tr, step := newTracker(t)
+	steps := map[string]*Step{}
+
+	for i := 0; i < 4; i++ {
+		name := fmt.Sprintf("db%d", i)
+		steps[name] = step(name, 30)
+		steps[name].Mutex = []string{"db"}
+	}
+
+	executeTracked(t, &Action{Steps: steps}, Options{Jobs: 4})
+
+	if tr.max != 1 {
+		t.Errorf("Execute() ran %d steps of the mutex group at once, want 1", tr.max)
+	}
+
+	for a := range steps {
+		for b := range steps {
+			if a != b && tr.started[a].Before(tr.started[b]) && tr.finished[a].After(tr.started[b]) {
+				t.Errorf("step %s overlapped with step %s", a, b)
+			}
+		}
+	}
+}
+
+func TestExecuter_Execute_StartsWhenDependenciesFinished(t *testing.T) {
+	tr, step := newTracker(t)
+
+	steps := map[string]*Step{
+		"fast": step("fast", 20),
+		"slow": step("slow", 500),
+		"next": step("next", 0),
+	}
+
+	steps["next"].Dependencies = []string{"fast"}
+
+	executeTracked(t, &Action{Steps: steps}, Options{Jobs: 4})
+
+	if !tr.started["next"].Before(tr.finished["slow"]) {
+		t.Errorf("step next started after step slow finished, want it to start once fast finished")
+	}
+}
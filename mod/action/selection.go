@@ -0,0 +1,72 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+// Selection restricts an execution to some steps of some actions. A nil
+// selection includes all steps.
+type Selection map[string]map[string]bool
+
+func (s Selection) Add(actionName string, stepNames ...string) {
+	if s[actionName] == nil {
+		s[actionName] = map[string]bool{}
+	}
+
+	for _, stepName := range stepNames {
+		s[actionName][stepName] = true
+	}
+}
+
+func (s Selection) IncludesAction(actionName string) bool {
+	if s == nil {
+		return true
+	}
+
+	return len(s[actionName]) > 0
+}
+
+func (s Selection) Includes(actionName string, stepName string) bool {
+	if s == nil {
+		return true
+	}
+
+	return s[actionName][stepName]
+}
+
+// Merge returns a selection including the steps of both selections.
+func (s Selection) Merge(o Selection) Selection {
+	if s == nil || o == nil {
+		return nil
+	}
+
+	merged := Selection{}
+
+	for _, selection := range []Selection{s, o} {
+		for actionName, stepNames := range selection {
+			for stepName := range stepNames {
+				merged.Add(actionName, stepName)
+			}
+		}
+	}
+
+	return merged
+}
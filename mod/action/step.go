@@ -23,9 +23,26 @@
 package action
 
 type Step struct {
-	Type         string         `json:"type" yaml:"type"`
+	Description  string         `json:"description,omitempty" yaml:"description,omitempty"`
+	Uses         string         `json:"uses,omitempty" yaml:"uses,omitempty"`
+	Type         string         `json:"type,omitempty" yaml:"type,omitempty"`
 	Dependencies []string       `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
 	Input        string         `json:"input,omitempty" yaml:"input,omitempty"`
 	Output       string         `json:"output,omitempty" yaml:"output,omitempty"`
 	Params       map[string]any `json:"params,omitempty" yaml:"params,omitempty"`
+	Timeout      Duration       `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Inputs       []string       `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+	Outputs      []string       `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+	OutputMode   OutputMode     `json:"output_mode,omitempty" yaml:"output_mode,omitempty"`
+	Mutex        []string       `json:"mutex,omitempty" yaml:"mutex,omitempty"`
+	Matrix       *Matrix        `json:"matrix,omitempty" yaml:"matrix,omitempty"`
+	Retry        *Retry         `json:"retry,omitempty" yaml:"retry,omitempty"`
+	If           string         `json:"if,omitempty" yaml:"if,omitempty"`
+	Unless       string         `json:"unless,omitempty" yaml:"unless,omitempty"`
+	// OnSkippedDependency overrides the rule of the action.
+	OnSkippedDependency SkipRule `json:"on_skipped_dependency,omitempty" yaml:"on_skipped_dependency,omitempty"`
+
+	// variables are set for the variants of an expanded step, like "GOOS" or
+	// "matrix.go".
+	variables map[string]any
 }
@@ -0,0 +1,318 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/chapterjason/j3n/modx/filepathx"
+	"github.com/chapterjason/j3n/modx/slicex"
+)
+
+var (
+	ErrUnknownArchiveFormat = errors.New("unknown archive format")
+
+	// ArchiveTime is the modification time of all archived files unless
+	// SOURCE_DATE_EPOCH is set, so archives of the same files are identical.
+	ArchiveTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+type archiveFile struct {
+	path string
+	name string
+	mode fs.FileMode
+}
+
+func init() {
+	MustRegister(
+		"archive", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			destination, ok := params["destination"].(string)
+
+			if !ok || destination == "" {
+				return nil, errors.New("destination is required")
+			}
+
+			format, _ := params["format"].(string)
+
+			if format == "" {
+				switch {
+				case strings.HasSuffix(destination, ".tar.gz"), strings.HasSuffix(destination, ".tgz"):
+					format = "tar.gz"
+				case strings.HasSuffix(destination, ".zip"):
+					format = "zip"
+				}
+			}
+
+			if format != "tar.gz" && format != "zip" {
+				return nil, errors.Wrap(ErrUnknownArchiveFormat, format)
+			}
+
+			files, err := getArchiveFiles(params)
+
+			if err != nil {
+				return nil, err
+			}
+
+			modified, err := getArchiveTime()
+
+			if err != nil {
+				return nil, err
+			}
+
+			if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+				return nil, errors.Wrap(err, "failed to create destination directory")
+			}
+
+			f, err := os.Create(destination)
+
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create archive")
+			}
+
+			if format == "zip" {
+				err = writeZip(f, files, modified)
+			} else {
+				err = writeTarGz(f, files, modified)
+			}
+
+			if err != nil {
+				f.Close()
+
+				return nil, errors.Wrapf(err, "failed to write %s", destination)
+			}
+
+			if err := f.Close(); err != nil {
+				return nil, errors.Wrapf(err, "failed to write %s", destination)
+			}
+
+			names := []string{}
+
+			for _, file := range files {
+				names = append(names, file.name)
+			}
+
+			log.Debugf("archived %d files to %s", len(files), destination)
+
+			return Outputs{
+				DefaultOutput: destination,
+				"files":       names,
+			}, nil
+		},
+	)
+}
+
+// getArchiveFiles returns the files matching the "include" patterns but none
+// of the "exclude" patterns, sorted by their names. Patterns and names are
+// relative to "directory", "prefix" is prepended to the names.
+func getArchiveFiles(params map[string]any) ([]archiveFile, error) {
+	directory := "."
+
+	if params["directory"] != nil {
+		directory = params["directory"].(string)
+	}
+
+	prefix, _ := params["prefix"].(string)
+
+	var include, exclude []string
+
+	switch value := params["include"].(type) {
+	case nil:
+		return nil, errors.New("include is required")
+	case string:
+		include = []string{value}
+	default:
+		include = slicex.ToString(value)
+	}
+
+	switch value := params["exclude"].(type) {
+	case nil:
+	case string:
+		exclude = []string{value}
+	default:
+		exclude = slicex.ToString(value)
+	}
+
+	found := map[string]archiveFile{}
+
+	add := func(p string, info fs.FileInfo) error {
+		rel, err := filepath.Rel(directory, p)
+
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		for _, pattern := range exclude {
+			if ok, err := filepathx.Match(pattern, rel); err != nil || ok {
+				return err
+			}
+		}
+
+		found[rel] = archiveFile{path: p, name: prefix + rel, mode: info.Mode().Perm()}
+
+		return nil
+	}
+
+	for _, pattern := range include {
+		matches, err := filepathx.Glob(filepath.Join(directory, pattern))
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern %s", pattern)
+		}
+
+		if len(matches) == 0 {
+			return nil, errors.Errorf("include %s matches no files", pattern)
+		}
+
+		for _, match := range matches {
+			err := filepath.Walk(
+				match, func(p string, info fs.FileInfo, err error) error {
+					if err != nil || info.IsDir() {
+						return err
+					}
+
+					return add(p, info)
+				},
+			)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	files := []archiveFile{}
+
+	for _, file := range found {
+		files = append(files, file)
+	}
+
+	sort.Slice(
+		files, func(i, j int) bool {
+			return files[i].name < files[j].name
+		},
+	)
+
+	return files, nil
+}
+
+func getArchiveTime() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+
+	if epoch == "" {
+		return ArchiveTime, nil
+	}
+
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid SOURCE_DATE_EPOCH")
+	}
+
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+func writeTarGz(w io.Writer, files []archiveFile, modified time.Time) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	for _, file := range files {
+		content, err := os.ReadFile(file.path)
+
+		if err != nil {
+			return err
+		}
+
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     file.name,
+			Mode:     int64(file.mode),
+			Size:     int64(len(content)),
+			ModTime:  modified,
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
+
+func writeZip(w io.Writer, files []archiveFile, modified time.Time) error {
+	zw := zip.NewWriter(w)
+
+	for _, file := range files {
+		header := &zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: modified,
+		}
+
+		header.SetMode(file.mode)
+
+		fw, err := zw.CreateHeader(header)
+
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(file.path)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(fw, f)
+		f.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArchiveAndChecksumSteps(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{"bin/app": "app", "bin/app.debug": "debug", "README.md": "readme"} {
+		p := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(p, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(step string, params map[string]any) Outputs {
+		t.Helper()
+
+		out, err := Steps[step](context.Background(), nil, params)
+
+		if err != nil {
+			t.Fatalf("%s error = %v", step, err)
+		}
+
+		return out.(Outputs)
+	}
+
+	archive := func(destination string) []byte {
+		t.Helper()
+
+		run(
+			"archive", map[string]any{
+				"destination": destination,
+				"directory":   dir,
+				"include":     []any{"bin", "*.md"},
+				"exclude":     "**/*.debug",
+				"prefix":      "app/",
+			},
+		)
+
+		content, err := os.ReadFile(destination)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return content
+	}
+
+	first := archive(filepath.Join(dir, "dist/app.tar.gz"))
+
+	// touching the files must not change the archive
+	if err := os.Chtimes(filepath.Join(dir, "bin/app"), time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if second := archive(filepath.Join(dir, "dist/app.tar.gz")); !bytes.Equal(first, second) {
+		t.Errorf("archive is not reproducible")
+	}
+
+	content := archive(filepath.Join(dir, "dist/app.zip"))
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+
+	if got := strings.Join(names, ","); got != "app/README.md,app/bin/app" {
+		t.Errorf("zip files = %s", got)
+	}
+
+	manifest := filepath.Join(dir, "dist/SHA256SUMS")
+
+	out := run("checksum", map[string]any{"files": filepath.Join(dir, "dist/*.*"), "manifest": manifest})
+
+	if len(out["checksums"].(map[string]any)) != 2 || !strings.Contains(out[DefaultOutput].(string), "  app.zip\n") {
+		t.Errorf("checksum output = %v", out[DefaultOutput])
+	}
+
+	run("checksum", map[string]any{"manifest": manifest, "verify": true})
+
+	if err := os.WriteFile(filepath.Join(dir, "dist/app.zip"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Steps["checksum"](context.Background(), nil, map[string]any{"manifest": manifest, "verify": true}); err == nil {
+		t.Errorf("checksum verify error = nil, want mismatch")
+	}
+}
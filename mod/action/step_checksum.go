@@ -0,0 +1,236 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown checksum algorithm")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+func init() {
+	MustRegister(
+		"checksum", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			algorithm := "sha256"
+
+			if params["algorithm"] != nil {
+				algorithm = params["algorithm"].(string)
+			}
+
+			if _, err := newHash(algorithm); err != nil {
+				return nil, err
+			}
+
+			manifest, _ := params["manifest"].(string)
+
+			if params["verify"] == true {
+				if manifest == "" {
+					return nil, errors.New("manifest is required to verify checksums")
+				}
+
+				return verifyChecksums(manifest, algorithm)
+			}
+
+			files, err := getPaths(params, "files", true)
+
+			if err != nil {
+				return nil, err
+			}
+
+			// names in the manifest are relative to it, like "sha256sum -c"
+			// expects when run in its directory.
+			directory := "."
+
+			if manifest != "" {
+				directory = filepath.Dir(manifest)
+			}
+
+			checksums := map[string]any{}
+			names := []string{}
+
+			for _, file := range files {
+				// the manifest of a previous run would end up in the new one
+				// if it matches the files.
+				if manifest != "" && isSamePath(file, manifest) {
+					continue
+				}
+
+				if info, err := os.Stat(file); err == nil && info.IsDir() {
+					continue
+				}
+
+				sum, err := hashFileWith(file, algorithm)
+
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to hash %s", file)
+				}
+
+				name, err := filepath.Rel(directory, file)
+
+				if err != nil {
+					return nil, err
+				}
+
+				name = filepath.ToSlash(name)
+				checksums[name] = sum
+				names = append(names, name)
+			}
+
+			sort.Strings(names)
+
+			var b strings.Builder
+
+			for _, name := range names {
+				fmt.Fprintf(&b, "%s  %s\n", checksums[name], name)
+			}
+
+			if manifest != "" {
+				if err := writeFile(manifest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644, b.String()); err != nil {
+					return nil, errors.Wrapf(err, "failed to write %s", manifest)
+				}
+
+				log.Debugf("wrote %d checksums to %s", len(names), manifest)
+			}
+
+			return Outputs{
+				DefaultOutput: b.String(),
+				"checksums":   checksums,
+			}, nil
+		},
+	)
+}
+
+// verifyChecksums checks every file listed in the manifest.
+func verifyChecksums(manifest string, algorithm string) (any, error) {
+	f, err := os.Open(manifest)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open manifest")
+	}
+
+	defer f.Close()
+
+	directory := filepath.Dir(manifest)
+	checksums := map[string]any{}
+	mismatches := []string{}
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			continue
+		}
+
+		want, name, ok := strings.Cut(line, " ")
+
+		if !ok {
+			return nil, errors.Errorf("invalid manifest line %q", line)
+		}
+
+		// "*" marks binary mode in manifests of sha256sum
+		name = strings.TrimPrefix(strings.TrimSpace(name), "*")
+
+		got, err := hashFileWith(filepath.Join(directory, name), algorithm)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to hash %s", name)
+		}
+
+		if got != want {
+			mismatches = append(mismatches, name)
+		}
+
+		checksums[name] = got
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read manifest")
+	}
+
+	if len(mismatches) > 0 {
+		return nil, errors.Wrap(ErrChecksumMismatch, strings.Join(mismatches, ", "))
+	}
+
+	return Outputs{
+		DefaultOutput: fmt.Sprintf("%d files verified", len(checksums)),
+		"checksums":   checksums,
+	}, nil
+}
+
+func isSamePath(a string, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+
+	return errA == nil && errB == nil && absA == absB
+}
+
+func newHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	}
+
+	return nil, errors.Wrap(ErrUnknownAlgorithm, algorithm)
+}
+
+func hashFileWith(p string, algorithm string) (string, error) {
+	h, err := newHash(algorithm)
+
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(p)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChecksumStep(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "SHA256SUMS")
+
+	for name, content := range map[string]string{"a.txt": "a", "b.txt": "b"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	params := map[string]any{"files": filepath.Join(dir, "*"), "manifest": manifest}
+
+	// the second run must not include the manifest of the first one
+	for i := 0; i < 2; i++ {
+		if _, err := Steps["checksum"](context.Background(), nil, params); err != nil {
+			t.Fatalf("checksum run %d error = %v", i, err)
+		}
+	}
+
+	content, err := os.ReadFile(manifest)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(content), "SHA256SUMS") || strings.Contains(string(content), "sub") || strings.Count(string(content), "\n") != 2 {
+		t.Errorf("checksum manifest = %q, want a.txt and b.txt", content)
+	}
+
+	if _, err := Steps["checksum"](context.Background(), nil, map[string]any{"manifest": manifest, "verify": true}); err != nil {
+		t.Errorf("checksum verify error = %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Steps["checksum"](context.Background(), nil, map[string]any{"manifest": manifest, "verify": true}); err == nil {
+		t.Errorf("checksum verify of a changed file got no error")
+	}
+}
@@ -24,7 +24,9 @@ package action
 
 import (
 	"bytes"
-	"fmt"
+	"context"
+	"encoding/json"
+	"io"
 	"os"
 	"os/exec"
 	"path"
@@ -32,103 +34,187 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/chapterjason/j3n/modx/execx"
 	"github.com/chapterjason/j3n/modx/slicex"
 )
 
 func init() {
+	MustRegisterCommand("exec", execCommand)
 	MustRegister(
-		"exec", func(input any, params map[string]any) (any, error) {
-			command := params["command"].(string)
+		"exec", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			cmd, err := execCommand(params)
 
-			continueOnError := false
-			ignoreExitCodes := []float64{}
-			printStdout := false
-			printStderr := false
+			if err != nil {
+				return nil, err
+			}
 
-			var args []string
+			return runCommand(ctx, cmd, input, params)
+		},
+	)
+}
 
-			var stdout, stderr bytes.Buffer
+func execCommand(params map[string]any) (*exec.Cmd, error) {
+	command, ok := params["command"].(string)
 
-			if params["args"] != nil {
-				args = slicex.ToString(params["args"])
-			}
+	if !ok {
+		return nil, errors.New("command is required")
+	}
 
-			cmd := exec.Command(command, args...)
+	var args []string
 
-			if params["directory"] != nil {
-				dir := params["directory"].(string)
+	if params["args"] != nil {
+		args = slicex.ToString(params["args"])
+	}
 
-				if !path.IsAbs(dir) {
-					wd, err := os.Getwd()
+	cmd := exec.Command(command, args...)
 
-					if err != nil {
-						return nil, errors.Wrap(err, "failed to get working directory")
-					}
+	if err := setupCommand(cmd, params); err != nil {
+		return nil, err
+	}
 
-					dir = path.Join(wd, dir)
-				}
+	return cmd, nil
+}
 
-				cmd.Dir = dir
-			}
+// setupCommand sets the working directory and the environment of the
+// command from the params "directory" and "env".
+func setupCommand(cmd *exec.Cmd, params map[string]any) error {
+	if params["directory"] != nil {
+		dir := params["directory"].(string)
 
-			if params["env"] != nil {
-				cmd.Env = slicex.ToString(params["env"])
-			}
+		if !path.IsAbs(dir) {
+			wd, err := os.Getwd()
 
-			if params["continue_on_error"] != nil {
-				continueOnError = params["continueOnError"].(bool)
+			if err != nil {
+				return errors.Wrap(err, "failed to get working directory")
 			}
 
-			if params["ignore_exit_codes"] != nil {
-				ignoreExitCodes = slicex.ToFloat(params["ignore_exit_codes"])
-			}
+			dir = path.Join(wd, dir)
+		}
 
-			if params["print_stdout"] != nil {
-				printStdout = params["print_stdout"].(bool)
-			}
+		cmd.Dir = dir
+	}
 
-			if params["print_stderr"] != nil {
-				printStderr = params["print_stderr"].(bool)
-			}
+	if params["env"] != nil {
+		cmd.Env = slicex.ToString(params["env"])
+	}
 
-			if input != nil {
-				is := input.(string)
-				cmd.Stdin = strings.NewReader(is)
-			}
+	return nil
+}
 
-			cmd.Stdout = &stdout
-			cmd.Stderr = &stderr
+// runCommand runs the command with the params "continue_on_error",
+// "ignore_exit_codes", "print_stdout", "print_stderr" and "parse_json"
+// shared by the exec and shell steps.
+func runCommand(ctx context.Context, cmd *exec.Cmd, input any, params map[string]any) (any, error) {
+	continueOnError := false
+	ignoreExitCodes := []float64{}
+	printStdout := false
+	printStderr := false
+	parseJson := false
 
-			err := cmd.Start()
+	var stdout, stderr bytes.Buffer
 
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to start command")
-			}
+	if params["continue_on_error"] != nil {
+		continueOnError = params["continue_on_error"].(bool)
+	}
 
-			err = cmd.Wait()
+	if params["ignore_exit_codes"] != nil {
+		ignoreExitCodes = slicex.ToFloat(params["ignore_exit_codes"])
+	}
 
-			if err != nil {
-				exitCode := cmd.ProcessState.ExitCode()
+	if params["print_stdout"] != nil {
+		printStdout = params["print_stdout"].(bool)
+	}
 
-				if !slicex.Contains(ignoreExitCodes, float64(exitCode)) && !continueOnError {
-					message := fmt.Sprintf("command \"%s\" failed\n", cmd.String())
-					message += fmt.Sprintf("    exit code: %d\n", exitCode)
-					message += fmt.Sprintf("    stderr: %s\n", strings.TrimSpace(stderr.String()))
-					message += fmt.Sprintf("    stdout: %s\n", strings.TrimSpace(stdout.String()))
+	if params["print_stderr"] != nil {
+		printStderr = params["print_stderr"].(bool)
+	}
 
-					return nil, errors.New(message)
-				}
-			}
+	if params["parse_json"] != nil {
+		parseJson = params["parse_json"].(bool)
+	}
+
+	if input != nil {
+		cmd.Stdin = strings.NewReader(FormatOutput(input))
+	}
 
-			if printStdout {
-				fmt.Print(stdout.String())
+	output := GetStepOutput(ctx)
+
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if output.Mode == OutputStream {
+		if printStdout {
+			w := output.Printer.Writer(output.Prefix)
+			defer w.Flush()
+
+			cmd.Stdout = io.MultiWriter(&stdout, w)
+		}
+
+		if printStderr {
+			w := output.Printer.Writer(output.Prefix)
+			defer w.Flush()
+
+			cmd.Stderr = io.MultiWriter(&stderr, w)
+		}
+	}
+
+	err := execx.Run(ctx, cmd)
+
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if err != nil && cmd.ProcessState == nil {
+		return nil, errors.Wrap(err, "failed to start command")
+	}
+
+	if err != nil {
+		exitCode := cmd.ProcessState.ExitCode()
+
+		if !slicex.Contains(ignoreExitCodes, float64(exitCode)) && !continueOnError {
+			return nil, &ExitError{
+				Command:  cmd.String(),
+				ExitCode: exitCode,
+				Stdout:   stdout.String(),
+				Stderr:   stderr.String(),
 			}
+		}
+	}
 
-			if printStderr {
-				fmt.Print(stderr.String())
+	if output.Mode == OutputGrouped {
+		var b bytes.Buffer
+
+		if printStdout && stdout.Len() > 0 {
+			b.Write(stdout.Bytes())
+
+			if !bytes.HasSuffix(stdout.Bytes(), []byte("\n")) {
+				b.WriteByte('\n')
 			}
+		}
 
-			return stdout.String() + stderr.String(), nil
-		},
-	)
+		if printStderr {
+			b.Write(stderr.Bytes())
+		}
+
+		output.Printer.Print(output.Prefix, b.Bytes())
+	}
+
+	outputs := Outputs{
+		DefaultOutput: stdout.String() + stderr.String(),
+		"stdout":      stdout.String(),
+		"stderr":      stderr.String(),
+		"exit_code":   cmd.ProcessState.ExitCode(),
+	}
+
+	if parseJson {
+		var v any
+
+		if err := json.Unmarshal(stdout.Bytes(), &v); err != nil {
+			return nil, errors.Wrap(err, "failed to parse stdout as json")
+		}
+
+		outputs["json"] = v
+	}
+
+	return outputs, nil
 }
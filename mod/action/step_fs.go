@@ -0,0 +1,364 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/chapterjason/j3n/modx/filepathx"
+	"github.com/chapterjason/j3n/modx/osx"
+	"github.com/chapterjason/j3n/modx/slicex"
+)
+
+func init() {
+	MustRegister(
+		"fs.copy", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			return transferFiles(params, "copy", osx.Copy)
+		},
+	)
+
+	MustRegister(
+		"fs.move", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			return transferFiles(params, "move", osx.Move)
+		},
+	)
+
+	MustRegister(
+		"fs.remove", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			paths, err := getPaths(params, "paths", false)
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, p := range paths {
+				if isDryRun(params) {
+					log.Infof("would remove %s", p)
+
+					continue
+				}
+
+				log.Debugf("removing %s", p)
+
+				if err := os.RemoveAll(p); err != nil {
+					return nil, errors.Wrapf(err, "failed to remove %s", p)
+				}
+			}
+
+			return fileOutputs(paths), nil
+		},
+	)
+
+	MustRegister(
+		"fs.mkdir", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			paths, err := getPaths(params, "paths", false)
+
+			if err != nil {
+				return nil, err
+			}
+
+			mode, err := getMode(params, 0755)
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, p := range paths {
+				if isDryRun(params) {
+					log.Infof("would create directory %s", p)
+
+					continue
+				}
+
+				log.Debugf("creating directory %s", p)
+
+				if err := os.MkdirAll(p, mode); err != nil {
+					return nil, errors.Wrapf(err, "failed to create directory %s", p)
+				}
+			}
+
+			return fileOutputs(paths), nil
+		},
+	)
+
+	MustRegister(
+		"fs.write", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			p, ok := params["path"].(string)
+
+			if !ok || p == "" {
+				return nil, errors.New("path is required")
+			}
+
+			content := ""
+
+			switch {
+			case params["content"] != nil:
+				content = FormatOutput(params["content"])
+			case input != nil:
+				content = FormatOutput(input)
+			}
+
+			mode, err := getMode(params, 0644)
+
+			if err != nil {
+				return nil, err
+			}
+
+			flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+
+			if params["append"] == true {
+				flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+			}
+
+			if isDryRun(params) {
+				log.Infof("would write %d bytes to %s", len(content), p)
+
+				return fileOutputs([]string{p}), nil
+			}
+
+			log.Debugf("writing %d bytes to %s", len(content), p)
+
+			if err := writeFile(p, flag, mode, content); err != nil {
+				return nil, errors.Wrapf(err, "failed to write %s", p)
+			}
+
+			return fileOutputs([]string{p}), nil
+		},
+	)
+
+	MustRegister(
+		"fs.template", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			source, ok := params["source"].(string)
+
+			if !ok || source == "" {
+				return nil, errors.New("source is required")
+			}
+
+			destination, ok := params["destination"].(string)
+
+			if !ok || destination == "" {
+				return nil, errors.New("destination is required")
+			}
+
+			info, err := os.Stat(source)
+
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to read template")
+			}
+
+			mode, err := getMode(params, info.Mode().Perm())
+
+			if err != nil {
+				return nil, err
+			}
+
+			template, err := os.ReadFile(source)
+
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to read template")
+			}
+
+			values, _ := params["variables"].(map[string]any)
+			variables := GetVariables(ctx)
+
+			content, err := ExpandPlaceholders(
+				string(template), func(name string) (any, error) {
+					if v, ok := values[name]; ok {
+						return v, nil
+					}
+
+					return variables(name)
+				},
+			)
+
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to render %s", source)
+			}
+
+			if isDryRun(params) {
+				log.Infof("would render %s to %s", source, destination)
+
+				return fileOutputs([]string{destination}), nil
+			}
+
+			log.Debugf("rendering %s to %s", source, destination)
+
+			if err := writeFile(destination, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode, content); err != nil {
+				return nil, errors.Wrapf(err, "failed to write %s", destination)
+			}
+
+			return fileOutputs([]string{destination}), nil
+		},
+	)
+}
+
+// transferFiles copies or moves the "source" files to "destination". The
+// destination is a directory which receives the sources by their names if
+// it ends with a slash, already is a directory or there are several sources.
+func transferFiles(params map[string]any, verb string, transfer func(src string, dst string) error) (any, error) {
+	sources, err := getPaths(params, "source", true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	destination, ok := params["destination"].(string)
+
+	if !ok || destination == "" {
+		return nil, errors.New("destination is required")
+	}
+
+	directory := len(sources) > 1 || strings.HasSuffix(destination, "/")
+
+	if info, err := os.Stat(destination); err == nil && info.IsDir() {
+		directory = true
+	}
+
+	targets := []string{}
+
+	for _, source := range sources {
+		target := destination
+
+		if directory {
+			target = filepath.Join(destination, filepath.Base(source))
+		}
+
+		targets = append(targets, target)
+
+		if isDryRun(params) {
+			log.Infof("would %s %s to %s", verb, source, target)
+
+			continue
+		}
+
+		log.Debugf("%s %s to %s", verb, source, target)
+
+		if err := transfer(source, target); err != nil {
+			return nil, errors.Wrapf(err, "failed to %s %s to %s", verb, source, target)
+		}
+	}
+
+	return fileOutputs(targets), nil
+}
+
+// getPaths returns the paths of the param, which is a path or a list of
+// paths. Patterns like "build/**/*.o" are expanded, paths without meta
+// characters are returned as they are.
+func getPaths(params map[string]any, name string, required bool) ([]string, error) {
+	var patterns []string
+
+	switch value := params[name].(type) {
+	case nil:
+	case string:
+		patterns = []string{value}
+	default:
+		patterns = slicex.ToString(value)
+	}
+
+	if len(patterns) == 0 {
+		return nil, errors.Errorf("%s is required", name)
+	}
+
+	paths := []string{}
+
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, "*?[") {
+			paths = append(paths, pattern)
+
+			continue
+		}
+
+		matches, err := filepathx.Glob(pattern)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern %s", pattern)
+		}
+
+		if len(matches) == 0 && required {
+			return nil, errors.Errorf("%s %s matches no files", name, pattern)
+		}
+
+		paths = append(paths, matches...)
+	}
+
+	return paths, nil
+}
+
+// getMode returns the "mode" param, which is an octal string like "0644".
+func getMode(params map[string]any, fallback fs.FileMode) (fs.FileMode, error) {
+	switch value := params["mode"].(type) {
+	case nil:
+		return fallback, nil
+	case string:
+		mode, err := strconv.ParseUint(value, 8, 32)
+
+		if err != nil {
+			return 0, errors.Wrapf(err, "invalid mode %s", value)
+		}
+
+		return fs.FileMode(mode), nil
+	}
+
+	// a number like 755 would be taken as decimal, so only strings are
+	// accepted.
+	return 0, errors.Errorf("invalid mode %v, expected an octal string like \"0644\"", params["mode"])
+}
+
+func isDryRun(params map[string]any) bool {
+	return params["dry_run"] == true
+}
+
+func writeFile(p string, flag int, mode fs.FileMode, content string) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(p, flag, mode)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+func fileOutputs(files []string) Outputs {
+	return Outputs{
+		DefaultOutput: strings.Join(files, "\n"),
+		"files":       files,
+	}
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFsSteps(t *testing.T) {
+	dir := t.TempDir()
+	ctx := WithVariables(
+		context.Background(), func(name string) (any, error) {
+			return "builtin", nil
+		},
+	)
+
+	run := func(step string, params map[string]any) {
+		t.Helper()
+
+		if _, err := Steps[step](ctx, nil, params); err != nil {
+			t.Fatalf("%s error = %v", step, err)
+		}
+	}
+
+	read := func(p string) string {
+		t.Helper()
+
+		content, err := os.ReadFile(filepath.Join(dir, p))
+
+		if err != nil {
+			t.Fatalf("read %s error = %v", p, err)
+		}
+
+		return string(content)
+	}
+
+	run("fs.mkdir", map[string]any{"paths": []any{filepath.Join(dir, "a/b")}})
+	run("fs.write", map[string]any{"path": filepath.Join(dir, "a/b/one.txt"), "content": "one"})
+	run("fs.write", map[string]any{"path": filepath.Join(dir, "a/b/one.txt"), "content": "+", "append": true})
+	run("fs.write", map[string]any{"path": filepath.Join(dir, "a/two.txt"), "content": "two"})
+	run("fs.copy", map[string]any{"source": filepath.Join(dir, "a/**/*.txt"), "destination": filepath.Join(dir, "c")})
+	run("fs.move", map[string]any{"source": filepath.Join(dir, "c/two.txt"), "destination": filepath.Join(dir, "d/2.txt")})
+	run("fs.remove", map[string]any{"paths": filepath.Join(dir, "a/*.txt")})
+	run("fs.remove", map[string]any{"paths": filepath.Join(dir, "c"), "dry_run": true})
+
+	if err := os.WriteFile(filepath.Join(dir, "t.tmpl"), []byte("{{NAME}} {{ OTHER }}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	run("fs.template", map[string]any{"source": filepath.Join(dir, "t.tmpl"), "destination": filepath.Join(dir, "t.txt"), "variables": map[string]any{"NAME": "name"}})
+
+	tests := map[string]string{
+		"c/one.txt": "one+",
+		"d/2.txt":   "two",
+		"t.txt":     "name builtin",
+	}
+
+	for p, want := range tests {
+		if got := read(p); got != want {
+			t.Errorf("%s = %q, want %q", p, got, want)
+		}
+	}
+
+	for _, p := range []string{"a/two.txt", "c/two.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, p)); !os.IsNotExist(err) {
+			t.Errorf("%s exists", p)
+		}
+	}
+
+	if info, err := os.Stat(filepath.Join(dir, "t.txt")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("t.txt mode = %v, %v", info, err)
+	}
+}
+
+func TestGetMode(t *testing.T) {
+	tests := map[string]struct {
+		mode    any
+		want    fs.FileMode
+		wantErr bool
+	}{
+		"default": {nil, 0644, false},
+		"octal":   {"0755", 0755, false},
+		"short":   {"755", 0755, false},
+		"number":  {float64(755), 0, true},
+		"invalid": {"0999", 0, true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := getMode(map[string]any{"mode": tt.mode}, 0644)
+
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("getMode() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/chapterjason/j3n/modx/slicex"
+)
+
+// DefaultGoBuildOutput is the path of the binaries built by a "go.build"
+// step without an output.
+const DefaultGoBuildOutput = "dist/{{NAME}}_{{GOOS}}_{{GOARCH}}{{EXT}}"
+
+var ErrInvalidPlatform = errors.New("invalid platform, expected os/arch")
+
+func init() {
+	MustRegisterCommand("go.build", goBuildCommand)
+	MustRegister(
+		"go.build", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			cmd, err := goBuildCommand(params)
+
+			if err != nil {
+				return nil, err
+			}
+
+			out, err := runCommand(ctx, cmd, nil, params)
+
+			if err != nil {
+				return nil, err
+			}
+
+			outputs := out.(Outputs)
+			outputs[DefaultOutput] = params["output"]
+			outputs["goos"] = params["goos"]
+			outputs["goarch"] = params["goarch"]
+
+			return outputs, nil
+		},
+	)
+}
+
+func goBuildCommand(params map[string]any) (*exec.Cmd, error) {
+	output, ok := params["output"].(string)
+
+	if !ok || output == "" {
+		return nil, errors.New("output is required")
+	}
+
+	pkg, err := getGoPackage(params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"build", "-o", output}
+
+	if params["tags"] != nil {
+		args = append(args, "-tags", strings.Join(slicex.ToString(params["tags"]), ","))
+	}
+
+	switch ldflags := params["ldflags"].(type) {
+	case nil:
+	case string:
+		args = append(args, "-ldflags", ldflags)
+	default:
+		args = append(args, "-ldflags", strings.Join(slicex.ToString(ldflags), " "))
+	}
+
+	if params["flags"] != nil {
+		args = append(args, slicex.ToString(params["flags"])...)
+	}
+
+	args = append(args, pkg)
+
+	cmd := exec.Command("go", args...)
+	commandParams := map[string]any{}
+
+	for key, value := range params {
+		if key != "env" {
+			commandParams[key] = value
+		}
+	}
+
+	if err := setupCommand(cmd, commandParams); err != nil {
+		return nil, err
+	}
+
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0")
+
+	if params["cgo"] == true {
+		cmd.Env = append(os.Environ(), "CGO_ENABLED=1")
+	}
+
+	if params["goos"] != nil {
+		cmd.Env = append(cmd.Env, "GOOS="+params["goos"].(string))
+	}
+
+	if params["goarch"] != nil {
+		cmd.Env = append(cmd.Env, "GOARCH="+params["goarch"].(string))
+	}
+
+	// unlike the exec step, the env of a build extends the environment
+	// instead of replacing it.
+	if params["env"] != nil {
+		cmd.Env = append(cmd.Env, slicex.ToString(params["env"])...)
+	}
+
+	return cmd, nil
+}
+
+// getGoPackage returns the package to build, which defaults to the current
+// directory.
+func getGoPackage(params map[string]any) (string, error) {
+	if params["package"] == nil {
+		return ".", nil
+	}
+
+	pkg, ok := params["package"].(string)
+
+	if !ok {
+		return "", errors.New("package must be a string")
+	}
+
+	return pkg, nil
+}
+
+// expandGoBuild returns one variant per platform of a "go.build" step, each
+// with the variables "NAME", "GOOS", "GOARCH" and "EXT" for the output.
+func expandGoBuild(step *Step) ([]variant, error) {
+	params := step.Params
+
+	name, _ := params["name"].(string)
+
+	if name == "" {
+		pkg, err := getGoPackage(params)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// the package is built in the directory, so steps in different
+		// directories get the names of their packages.
+		if directory, ok := params["directory"].(string); ok && !filepath.IsAbs(pkg) {
+			pkg = filepath.Join(directory, pkg)
+		}
+
+		abs, err := filepath.Abs(pkg)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to resolve package")
+		}
+
+		name = filepath.Base(abs)
+	}
+
+	platforms := [][2]string{}
+
+	if params["platforms"] != nil {
+		for _, platform := range slicex.ToString(params["platforms"]) {
+			goos, goarch, ok := strings.Cut(platform, "/")
+
+			if !ok || goos == "" || goarch == "" {
+				return nil, errors.Wrap(ErrInvalidPlatform, platform)
+			}
+
+			platforms = append(platforms, [2]string{goos, goarch})
+		}
+	} else {
+		goos, _ := params["goos"].(string)
+		goarch, _ := params["goarch"].(string)
+
+		if goos == "" {
+			goos = runtime.GOOS
+		}
+
+		if goarch == "" {
+			goarch = runtime.GOARCH
+		}
+
+		platforms = append(platforms, [2]string{goos, goarch})
+	}
+
+	variants := []variant{}
+
+	for _, platform := range platforms {
+		variantParams := map[string]any{}
+
+		for key, value := range params {
+			if key != "platforms" {
+				variantParams[key] = value
+			}
+		}
+
+		if variantParams["output"] == nil {
+			variantParams["output"] = DefaultGoBuildOutput
+		}
+
+		variantParams["goos"] = platform[0]
+		variantParams["goarch"] = platform[1]
+
+		ext := ""
+
+		if platform[0] == "windows" {
+			ext = ".exe"
+		}
+
+		v := step.withVariables(
+			map[string]any{
+				"NAME":   name,
+				"GOOS":   platform[0],
+				"GOARCH": platform[1],
+				"EXT":    ext,
+			},
+		)
+		v.Params = variantParams
+
+		suffix := ""
+
+		if params["platforms"] != nil {
+			suffix = platform[0] + "_" + platform[1]
+		}
+
+		variants = append(variants, variant{suffix: suffix, step: v})
+	}
+
+	return variants, nil
+}
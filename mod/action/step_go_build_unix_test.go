@@ -0,0 +1,96 @@
+//go:build !windows
+
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoBuildStep(t *testing.T) {
+	dir := t.TempDir()
+
+	// the fake go binary records its arguments and environment.
+	script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "args") + "\nenv > " + filepath.Join(dir, "env") + "\n"
+
+	if err := os.WriteFile(filepath.Join(dir, "go"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("J3N_TEST", "inherited")
+
+	out, err := Steps["go.build"](
+		context.Background(), nil, map[string]any{
+			"output":  "dist/app",
+			"package": "./cmd/app",
+			"tags":    []any{"a", "b"},
+			"goos":    "linux",
+			"goarch":  "arm64",
+			"env":     []any{"GOFLAGS=-trimpath"},
+		},
+	)
+
+	if err != nil {
+		t.Fatalf("go.build error = %v", err)
+	}
+
+	outputs := out.(Outputs)
+
+	for name, want := range map[string]any{DefaultOutput: "dist/app", "goos": "linux", "goarch": "arm64", "exit_code": 0} {
+		if outputs[name] != want {
+			t.Errorf("go.build output %s = %v, want %v", name, outputs[name], want)
+		}
+	}
+
+	args, err := os.ReadFile(filepath.Join(dir, "args"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := strings.TrimSpace(string(args)), "build -o dist/app -tags a,b ./cmd/app"; got != want {
+		t.Errorf("go.build args = %q, want %q", got, want)
+	}
+
+	env, err := os.ReadFile(filepath.Join(dir, "env"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"CGO_ENABLED=0", "GOOS=linux", "GOARCH=arm64", "GOFLAGS=-trimpath", "J3N_TEST=inherited"} {
+		if !strings.Contains("\n"+string(env), "\n"+want+"\n") {
+			t.Errorf("go.build env does not contain %s", want)
+		}
+	}
+
+	if _, err := Steps["go.build"](context.Background(), nil, map[string]any{"output": "dist/app", "package": 1}); err == nil {
+		t.Errorf("go.build with a package which is not a string got no error")
+	}
+}
@@ -0,0 +1,182 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/chapterjason/j3n/modx/slicex"
+)
+
+func init() {
+	MustRegister(
+		"http", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			url, ok := params["url"].(string)
+
+			if !ok || url == "" {
+				return nil, errors.New("url is required")
+			}
+
+			method := http.MethodGet
+
+			if params["method"] != nil {
+				value, ok := params["method"].(string)
+
+				if !ok {
+					return nil, errors.New("method must be a string")
+				}
+
+				method = strings.ToUpper(value)
+			}
+
+			var body io.Reader
+
+			switch {
+			case params["body_file"] != nil:
+				file, ok := params["body_file"].(string)
+
+				if !ok {
+					return nil, errors.New("body_file must be a string")
+				}
+
+				f, err := os.Open(file)
+
+				if err != nil {
+					return nil, errors.Wrap(err, "failed to open body file")
+				}
+
+				defer f.Close()
+
+				body = f
+			case params["body"] != nil:
+				body = strings.NewReader(FormatOutput(params["body"]))
+			case input != nil:
+				body = strings.NewReader(FormatOutput(input))
+			}
+
+			client := &http.Client{}
+
+			if params["timeout"] != nil {
+				value, ok := params["timeout"].(string)
+
+				if !ok {
+					return nil, errors.New("timeout must be a string like \"30s\"")
+				}
+
+				timeout, err := time.ParseDuration(value)
+
+				if err != nil {
+					return nil, errors.Wrap(err, "invalid timeout")
+				}
+
+				client.Timeout = timeout
+			}
+
+			request, err := http.NewRequestWithContext(ctx, method, url, body)
+
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid request")
+			}
+
+			if headers, ok := params["headers"].(map[string]any); ok {
+				for name, value := range headers {
+					request.Header.Set(name, FormatOutput(value))
+				}
+			}
+
+			log.Debugf("%s %s", method, url)
+
+			response, err := client.Do(request)
+
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s %s failed", method, url)
+			}
+
+			defer response.Body.Close()
+
+			content, err := io.ReadAll(response.Body)
+
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to read response")
+			}
+
+			if !expectedStatus(params, response.StatusCode) {
+				return nil, errors.Errorf("%s %s returned unexpected status %s\n    body: %s\n", method, url, response.Status, strings.TrimSpace(string(content)))
+			}
+
+			headers := map[string]any{}
+
+			for name := range response.Header {
+				headers[name] = response.Header.Get(name)
+			}
+
+			outputs := Outputs{
+				DefaultOutput: string(content),
+				"body":        string(content),
+				"status":      response.StatusCode,
+				"headers":     headers,
+			}
+
+			mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+
+			if params["parse_json"] == true || mediaType == "application/json" {
+				var v any
+
+				if err := json.Unmarshal(content, &v); err != nil {
+					return nil, errors.Wrap(err, "failed to parse response as json")
+				}
+
+				outputs[DefaultOutput] = v
+				outputs["json"] = v
+			}
+
+			return outputs, nil
+		},
+	)
+}
+
+// expectedStatus returns whether the status is one of "expected_status",
+// which defaults to all 2xx codes.
+func expectedStatus(params map[string]any, status int) bool {
+	if params["expected_status"] == nil {
+		return status >= 200 && status < 300
+	}
+
+	for _, expected := range slicex.ToFloat(params["expected_status"]) {
+		if int(expected) == status {
+			return true
+		}
+	}
+
+	return false
+}
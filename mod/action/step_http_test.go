@@ -0,0 +1,102 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHttpStep(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/health":
+					w.Header().Set("Content-Type", "application/json")
+					w.Write([]byte(`{"status":"ok"}`))
+				case "/upload":
+					body, _ := io.ReadAll(r.Body)
+
+					w.WriteHeader(http.StatusCreated)
+					w.Write([]byte(r.Method + " " + r.Header.Get("X-Token") + " " + string(body)))
+				default:
+					http.NotFound(w, r)
+				}
+			},
+		),
+	)
+	defer server.Close()
+
+	step := Steps["http"]
+
+	out, err := step(context.Background(), nil, map[string]any{"url": server.URL + "/health"})
+
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+
+	if got, _ := json.Marshal(out.(Outputs)[DefaultOutput]); string(got) != `{"status":"ok"}` {
+		t.Errorf("GET output = %s", got)
+	}
+
+	out, err = step(
+		context.Background(), "artifact", map[string]any{
+			"url":             server.URL + "/upload",
+			"method":          "put",
+			"headers":         map[string]any{"X-Token": "secret"},
+			"expected_status": []any{float64(201)},
+		},
+	)
+
+	if err != nil {
+		t.Fatalf("PUT error = %v", err)
+	}
+
+	if got := out.(Outputs)[DefaultOutput]; got != "PUT secret artifact" {
+		t.Errorf("PUT output = %v", got)
+	}
+
+	if out.(Outputs)["status"] != http.StatusCreated {
+		t.Errorf("PUT status = %v", out.(Outputs)["status"])
+	}
+
+	_, err = step(context.Background(), nil, map[string]any{"url": server.URL + "/missing"})
+
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("GET missing error = %v", err)
+	}
+
+	for _, params := range []map[string]any{{"timeout": float64(30)}, {"method": float64(1)}, {"body_file": true}} {
+		params["url"] = server.URL + "/health"
+
+		if _, err := step(context.Background(), nil, params); err == nil {
+			t.Errorf("GET with params %v got no error", params)
+		}
+	}
+}
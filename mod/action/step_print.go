@@ -23,13 +23,14 @@
 package action
 
 import (
+	"context"
 	"fmt"
 	"os"
 )
 
 func init() {
 	MustRegister(
-		"print", func(input any, params map[string]any) (any, error) {
+		"print", func(ctx context.Context, input any, params map[string]any) (any, error) {
 			out := os.Stdout
 
 			if params["stream"] == "stderr" {
@@ -52,7 +53,7 @@ func init() {
 			case bool:
 				fmt.Fprintf(out, "%t", input)
 			default:
-				return nil, fmt.Errorf("unsupported type %T", input)
+				fmt.Fprint(out, FormatOutput(input))
 			}
 
 			return nil, nil
@@ -0,0 +1,126 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/chapterjason/j3n/modx/slicex"
+)
+
+var (
+	// DefaultShell runs scripts with sh and exits on the first failing
+	// command.
+	DefaultShell = []string{"sh", "-ec"}
+
+	posixShells = map[string]bool{"sh": true, "bash": true, "dash": true, "ksh": true, "zsh": true, "ash": true}
+
+	// commandOptionExpression matches short options including "c", like
+	// "-c" or "-ec".
+	commandOptionExpression = regexp.MustCompile(`^-[a-zA-Z]*c[a-zA-Z]*$`)
+)
+
+func init() {
+	MustRegisterCommand("shell", shellCommand)
+	MustRegister(
+		"shell", func(ctx context.Context, input any, params map[string]any) (any, error) {
+			cmd, err := shellCommand(params)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return runCommand(ctx, cmd, input, params)
+		},
+	)
+}
+
+func shellCommand(params map[string]any) (*exec.Cmd, error) {
+	shell := DefaultShell
+
+	switch value := params["shell"].(type) {
+	case nil:
+	case string:
+		shell = strings.Fields(value)
+	default:
+		shell = slicex.ToString(value)
+	}
+
+	if len(shell) == 0 {
+		return nil, errors.New("shell must not be empty")
+	}
+
+	var script string
+
+	switch {
+	case params["script"] != nil && params["file"] != nil:
+		return nil, errors.New("either script or file must be given, not both")
+	case params["script"] != nil:
+		script = params["script"].(string)
+	case params["file"] != nil:
+		content, err := os.ReadFile(params["file"].(string))
+
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read script file")
+		}
+
+		script = string(content)
+	default:
+		return nil, errors.New("script or file is required")
+	}
+
+	args := append([]string{}, shell[1:]...)
+
+	// the script is passed like "sh -ec <script>", so "-c" is added to
+	// shells like "bash -euo pipefail" which would take it as a file.
+	if posixShells[filepath.Base(shell[0])] && !hasCommandOption(args) {
+		args = append(args, "-c")
+	}
+
+	args = append(args, script)
+
+	cmd := exec.Command(shell[0], args...)
+
+	if err := setupCommand(cmd, params); err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
+
+func hasCommandOption(args []string) bool {
+	for _, arg := range args {
+		if commandOptionExpression.MatchString(arg) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShellCommand_Errors(t *testing.T) {
+	tests := map[string]struct {
+		params map[string]any
+		err    string
+	}{
+		"script and file": {map[string]any{"script": "true", "file": "run.sh"}, "either script or file must be given, not both"},
+		"no script":       {map[string]any{}, "script or file is required"},
+		"empty shell":     {map[string]any{"script": "true", "shell": ""}, "shell must not be empty"},
+		"missing file":    {map[string]any{"file": filepath.Join(t.TempDir(), "missing.sh")}, "failed to read script file"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := shellCommand(tt.params)
+
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("shellCommand() error = %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
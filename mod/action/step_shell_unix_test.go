@@ -0,0 +1,67 @@
+//go:build !windows
+
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShellStep(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "run.sh")
+
+	if err := os.WriteFile(file, []byte("echo file \"$0\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		params map[string]any
+		want   string
+	}{
+		"script":          {map[string]any{"script": "echo one\necho two"}, "one\ntwo\n"},
+		"file":            {map[string]any{"file": file}, "file sh\n"},
+		"shell":           {map[string]any{"script": "echo $0", "shell": "bash -c"}, "bash\n"},
+		"shell list":      {map[string]any{"script": "echo $0", "shell": []any{"bash", "-c"}}, "bash\n"},
+		"shell options":   {map[string]any{"script": "set -o | grep -c 'pipefail.*on'", "shell": "bash -euo pipefail"}, "1\n"},
+		"shell without c": {map[string]any{"script": "echo $0", "shell": "bash -e"}, "bash\n"},
+		"exit on fail":    {map[string]any{"script": "false\necho unreachable", "continue_on_error": true}, ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			out, err := Steps["shell"](context.Background(), nil, tt.params)
+
+			if err != nil {
+				t.Fatalf("shell error = %v", err)
+			}
+
+			if got := out.(Outputs)["stdout"]; got != tt.want {
+				t.Errorf("shell stdout = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -23,16 +23,26 @@
 package action
 
 import (
+	"context"
+	"os/exec"
+
 	"github.com/pkg/errors"
 )
 
 var (
 	Steps = map[string]StepFunc{}
 
+	// Commands return the commands run by the steps of a type, which are
+	// shown by a plan instead of running them.
+	Commands = map[string]CommandFunc{}
+
 	ErrStepRunnerAlreadyRegistered = errors.New("StepName runner already registered")
+	ErrCommandAlreadyRegistered    = errors.New("StepName command already registered")
 )
 
-type StepFunc = func(input any, params map[string]any) (any, error)
+type StepFunc = func(ctx context.Context, input any, params map[string]any) (any, error)
+
+type CommandFunc = func(params map[string]any) (*exec.Cmd, error)
 
 func Register(name string, factory StepFunc) error {
 	if _, ok := Steps[name]; ok {
@@ -49,3 +59,19 @@ func MustRegister(n string, r StepFunc) {
 		panic(err)
 	}
 }
+
+func RegisterCommand(name string, command CommandFunc) error {
+	if _, ok := Commands[name]; ok {
+		return ErrCommandAlreadyRegistered
+	}
+
+	Commands[name] = command
+
+	return nil
+}
+
+func MustRegisterCommand(n string, c CommandFunc) {
+	if err := RegisterCommand(n, c); err != nil {
+		panic(err)
+	}
+}
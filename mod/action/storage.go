@@ -0,0 +1,171 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultOutput is the name of the output which is stored under the key
+// configured as output of a step.
+const DefaultOutput = "output"
+
+// Outputs are the named outputs published by a step runner, like "stdout" or
+// "exit_code". A runner returning anything else publishes it as its
+// DefaultOutput.
+type Outputs map[string]any
+
+// Storage holds the outputs of executed steps and is safe for concurrent
+// use.
+type Storage struct {
+	mutex   sync.RWMutex
+	values  map[string]any
+	outputs map[string]map[string]Outputs
+	actions map[string]Outputs
+}
+
+func NewStorage() *Storage {
+	return &Storage{
+		values:  make(map[string]any),
+		outputs: make(map[string]map[string]Outputs),
+		actions: make(map[string]Outputs),
+	}
+}
+
+// Set stores a value under a key, like the output key of a step.
+func (s *Storage) Set(key string, value any) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.values[key] = value
+}
+
+// Publish stores all outputs of a step, so they can be referenced as
+// "step.name" by the other steps of the action.
+func (s *Storage) Publish(actionName string, stepName string, outputs Outputs) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.outputs[actionName] == nil {
+		s.outputs[actionName] = map[string]Outputs{}
+	}
+
+	s.outputs[actionName][stepName] = outputs
+}
+
+// PublishAction stores the outputs of an action, so they can be referenced
+// as "action.name" by the steps of other actions.
+func (s *Storage) PublishAction(actionName string, outputs Outputs) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.actions[actionName] = outputs
+}
+
+// Resolve returns the value of a reference made by a step of the given
+// action. References like "build.stdout" are resolved to the output of a
+// step of the action, qualified references like "build.compile",
+// "build.compile.stdout" or "build.binary" to the output of a step or the
+// output of another action and everything else to a value stored with Set.
+func (s *Storage) Resolve(actionName string, reference string) (any, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if v, ok := s.getStepOutput(actionName, reference); ok {
+		return v, nil
+	}
+
+	if name, rest, ok := splitReference(reference); ok {
+		if outputs, ok := s.outputs[name][rest]; ok {
+			if v, ok := outputs[DefaultOutput]; ok {
+				return v, nil
+			}
+		}
+
+		if v, ok := s.getStepOutput(name, rest); ok {
+			return v, nil
+		}
+
+		if v, ok := s.actions[name][rest]; ok {
+			return v, nil
+		}
+	}
+
+	if v, ok := s.values[reference]; ok {
+		return v, nil
+	}
+
+	return nil, ErrOutputNotFound
+}
+
+// getStepOutput returns the output of a reference like "build.stdout" to a
+// step of the action.
+func (s *Storage) getStepOutput(actionName string, reference string) (any, bool) {
+	i := strings.LastIndex(reference, ".")
+
+	if i <= 0 {
+		return nil, false
+	}
+
+	v, ok := s.outputs[actionName][reference[:i]][reference[i+1:]]
+
+	return v, ok
+}
+
+func toOutputs(out any) Outputs {
+	if outputs, ok := out.(Outputs); ok {
+		return outputs
+	}
+
+	if out == nil {
+		return Outputs{}
+	}
+
+	return Outputs{DefaultOutput: out}
+}
+
+// FormatOutput returns the text of an output, which is used whenever it is
+// passed to a step expecting text. Structured values are encoded as json.
+func FormatOutput(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+		return fmt.Sprint(t)
+	}
+
+	b, err := json.Marshal(v)
+
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
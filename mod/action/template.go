@@ -0,0 +1,333 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chapterjason/j3n/modx/slicex"
+	"github.com/chapterjason/j3n/modx/viperx"
+)
+
+// ResolveTemplates returns a list in which every step using a template is
+// merged onto the template and every action extending another action is
+// merged onto that action, see merge for the rules.
+func (l *List) ResolveTemplates() (*List, error) {
+	problems := []Problem{}
+
+	templates := &inheritance{
+		kind:     "template",
+		problems: &problems,
+		path: func(name string) string {
+			return "templates." + name + ".uses"
+		},
+		get: func(name string) (map[string]any, string, bool) {
+			step, ok := l.Templates[name]
+
+			if !ok {
+				return nil, "", false
+			}
+
+			return toConfig(step), step.Uses, true
+		},
+	}
+
+	actions := &inheritance{
+		kind:     "action",
+		problems: &problems,
+		path: func(name string) string {
+			return "actions." + name + ".extends"
+		},
+		get: func(name string) (map[string]any, string, bool) {
+			action, ok := l.Actions[name]
+
+			if !ok {
+				return nil, "", false
+			}
+
+			return toConfig(action), action.Extends, true
+		},
+	}
+
+	list := &List{Actions: map[string]*Action{}}
+
+	for _, actionName := range l.GetNames() {
+		config, ok := actions.resolve(actionName, nil)
+
+		if !ok {
+			continue
+		}
+
+		delete(config, "extends")
+
+		steps, _ := config["steps"].(map[string]any)
+
+		stepNames := []string{}
+
+		for stepName := range steps {
+			stepNames = append(stepNames, stepName)
+		}
+
+		sort.Strings(stepNames)
+
+		for _, stepName := range stepNames {
+			step, _ := steps[stepName].(map[string]any)
+			uses, _ := step["uses"].(string)
+
+			if uses == "" {
+				continue
+			}
+
+			path := fmt.Sprintf("actions.%s.steps.%s.uses", actionName, stepName)
+
+			if _, ok := l.Templates[uses]; !ok {
+				problems = append(problems, Problem{Path: path, Message: fmt.Sprintf("unknown template %q", uses)})
+
+				continue
+			}
+
+			template, ok := templates.resolve(uses, nil)
+
+			if !ok {
+				continue
+			}
+
+			step = merge(template, step)
+			delete(step, "uses")
+
+			steps[stepName] = step
+		}
+
+		var action Action
+
+		if err := viperx.Transcode(config, &action); err != nil {
+			problems = append(problems, Problem{Path: "actions." + actionName, Message: err.Error()})
+
+			continue
+		}
+
+		list.Actions[actionName] = &action
+	}
+
+	if err := toValidationError(problems); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// inheritance resolves configurations which extend other configurations of
+// the same kind, like templates using other templates.
+type inheritance struct {
+	kind     string
+	problems *[]Problem
+	// path returns the path of the field naming the base of a configuration.
+	path func(name string) string
+	// get returns the configuration and the name of its base.
+	get      func(name string) (map[string]any, string, bool)
+	resolved map[string]map[string]any
+	failed   map[string]bool
+}
+
+// resolve returns the configuration merged onto its bases, chain are the
+// configurations extending it.
+func (i *inheritance) resolve(name string, chain []string) (map[string]any, bool) {
+	if config, ok := i.resolved[name]; ok {
+		return copyConfig(config), true
+	}
+
+	if i.failed[name] {
+		return nil, false
+	}
+
+	config, base, _ := i.get(name)
+	chain = append(chain, name)
+
+	if base != "" {
+		parent, ok := i.resolveBase(name, base, chain)
+
+		if !ok {
+			i.fail(chain)
+
+			return nil, false
+		}
+
+		config = merge(parent, config)
+	}
+
+	if i.resolved == nil {
+		i.resolved = map[string]map[string]any{}
+	}
+
+	i.resolved[name] = config
+
+	return copyConfig(config), true
+}
+
+func (i *inheritance) resolveBase(name string, base string, chain []string) (map[string]any, bool) {
+	if index := slicex.IndexOf(chain, base); index >= 0 {
+		cycle := append(append([]string{}, chain[index:]...), base)
+
+		*i.problems = append(*i.problems, Problem{Path: i.path(name), Message: fmt.Sprintf("%s cycle %s", i.kind, strings.Join(cycle, " -> "))})
+
+		return nil, false
+	}
+
+	if _, _, ok := i.get(base); !ok {
+		*i.problems = append(*i.problems, Problem{Path: i.path(name), Message: fmt.Sprintf("unknown %s %q", i.kind, base)})
+
+		return nil, false
+	}
+
+	return i.resolve(base, chain)
+}
+
+// fail marks the chain as failed, so its problems are only reported once.
+func (i *inheritance) fail(chain []string) {
+	if i.failed == nil {
+		i.failed = map[string]bool{}
+	}
+
+	for _, name := range chain {
+		i.failed[name] = true
+	}
+}
+
+// merge returns the configuration of base overridden by the configuration
+// of override. Maps are merged recursively, dependencies are appended to
+// the dependencies of base without duplicates, env lists like ["A=1"] are
+// merged by the name of the variable, null values are ignored and every
+// other value replaces the value of base.
+func merge(base map[string]any, override map[string]any) map[string]any {
+	merged := copyConfig(base)
+
+	for key, value := range override {
+		if value == nil {
+			continue
+		}
+
+		b, ok := merged[key]
+
+		if !ok {
+			merged[key] = value
+
+			continue
+		}
+
+		bm, bIsMap := b.(map[string]any)
+		om, oIsMap := value.(map[string]any)
+
+		if bIsMap && oIsMap {
+			merged[key] = merge(bm, om)
+
+			continue
+		}
+
+		bl, bIsList := b.([]any)
+		ol, oIsList := value.([]any)
+
+		if bIsList && oIsList {
+			switch key {
+			case "dependencies":
+				merged[key] = mergeDependencies(bl, ol)
+
+				continue
+			case "env":
+				merged[key] = mergeEnv(bl, ol)
+
+				continue
+			}
+		}
+
+		merged[key] = value
+	}
+
+	return merged
+}
+
+func mergeDependencies(base []any, override []any) []any {
+	merged := append([]any{}, base...)
+
+	for _, dep := range override {
+		if !slicex.Contains(merged, dep) {
+			merged = append(merged, dep)
+		}
+	}
+
+	return merged
+}
+
+func mergeEnv(base []any, override []any) []any {
+	merged := append([]any{}, base...)
+
+	name := func(v any) string {
+		s := fmt.Sprint(v)
+
+		return strings.SplitN(s, "=", 2)[0]
+	}
+
+	for _, variable := range override {
+		replaced := false
+
+		for i, existing := range merged {
+			if name(existing) == name(variable) {
+				merged[i] = variable
+				replaced = true
+			}
+		}
+
+		if !replaced {
+			merged = append(merged, variable)
+		}
+	}
+
+	return merged
+}
+
+// toConfig returns the json representation of a step or an action, which is
+// merged with the representation of its template.
+func toConfig(v any) map[string]any {
+	config := map[string]any{}
+
+	_ = viperx.Transcode(v, &config)
+
+	return config
+}
+
+// copyConfig returns a deep copy of the maps of the configuration, so
+// resolved configurations can be merged more than once.
+func copyConfig(config map[string]any) map[string]any {
+	copied := make(map[string]any, len(config))
+
+	for key, value := range config {
+		if m, ok := value.(map[string]any); ok {
+			value = copyConfig(m)
+		}
+
+		copied[key] = value
+	}
+
+	return copied
+}
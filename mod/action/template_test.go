@@ -0,0 +1,111 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestList_ResolveTemplates(t *testing.T) {
+	list := &List{
+		Templates: map[string]*Step{
+			"go": {
+				Type:   "exec",
+				Params: map[string]any{"command": "go", "env": []any{"CGO_ENABLED=0", "GOFLAGS=-mod=mod"}},
+			},
+			"test": {
+				Uses:         "go",
+				Dependencies: []string{"fmt"},
+				Params:       map[string]any{"args": []any{"test", "./..."}},
+			},
+		},
+		Actions: map[string]*Action{
+			"base": {
+				Description: "base",
+				Steps: map[string]*Step{
+					"fmt": {Uses: "go", Params: map[string]any{"args": []any{"fmt", "./..."}}},
+				},
+			},
+			"check": {
+				Extends: "base",
+				Steps: map[string]*Step{
+					"test": {
+						Uses:         "test",
+						Dependencies: []string{"vet"},
+						Params:       map[string]any{"env": []any{"CGO_ENABLED=1"}, "args": []any{"test", "-race", "./..."}},
+					},
+					"vet": {Uses: "go", Params: map[string]any{"args": []any{"vet", "./..."}}},
+				},
+			},
+		},
+	}
+
+	resolved, err := list.ResolveTemplates()
+
+	if err != nil {
+		t.Fatalf("ResolveTemplates() error = %v", err)
+	}
+
+	check := resolved.Actions["check"]
+
+	if check.Description != "base" || check.Extends != "" || len(check.Steps) != 3 {
+		t.Fatalf("ResolveTemplates() got action %+v", check)
+	}
+
+	want := &Step{
+		Type:         "exec",
+		Dependencies: []string{"fmt", "vet"},
+		Params: map[string]any{
+			"command": "go",
+			"env":     []any{"CGO_ENABLED=1", "GOFLAGS=-mod=mod"},
+			"args":    []any{"test", "-race", "./..."},
+		},
+	}
+
+	if got := check.Steps["test"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolveTemplates() got step %+v, want %+v", got, want)
+	}
+
+	list.Templates["go"].Uses = "test"
+	list.Actions["check"].Extends = "missing"
+
+	_, err = list.ResolveTemplates()
+
+	var invalid *ValidationError
+
+	if !errors.As(err, &invalid) {
+		t.Fatalf("ResolveTemplates() error = %v, want *ValidationError", err)
+	}
+
+	problems := []Problem{
+		{Path: "templates.test.uses", Message: "template cycle go -> test -> go"},
+		{Path: "actions.check.extends", Message: `unknown action "missing"`},
+	}
+
+	if !reflect.DeepEqual(invalid.Problems, problems) {
+		t.Errorf("ResolveTemplates() problems = %v, want %v", invalid.Problems, problems)
+	}
+}
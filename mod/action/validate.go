@@ -0,0 +1,248 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/chapterjason/j3n/mod/topology"
+	"github.com/chapterjason/j3n/modx/slicex"
+)
+
+// Problem is a mistake in the configuration at a path like
+// "actions.check.steps.build.dependencies[0]".
+type Problem struct {
+	Path    string
+	Message string
+}
+
+// ValidationError lists all problems of the configuration, so they can be
+// fixed at once.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := []string{"invalid configuration:"}
+
+	for _, problem := range e.Problems {
+		lines = append(lines, fmt.Sprintf("  %s: %s", problem.Path, problem.Message))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks the dependencies of all actions and their steps for
+// cycles, self dependencies and references to undefined actions or steps.
+func (l *List) Validate() error {
+	problems := getGraphProblems(
+		l.getDependencyGraph(), "action", func(key string) string {
+			return "actions." + key + ".dependencies"
+		},
+	)
+
+	for _, name := range l.GetNames() {
+		problems = append(problems, l.Actions[name].validate("actions."+name)...)
+		problems = append(problems, l.getReferenceProblems(name)...)
+	}
+
+	// cycles through steps of other actions are only searched in a
+	// configuration which is valid otherwise, as they would repeat the
+	// problems found so far.
+	if len(problems) == 0 {
+		problems = l.getStepGraphProblems()
+	}
+
+	return toValidationError(problems)
+}
+
+// getReferenceProblems returns the problems of the dependencies of the
+// steps of the action on steps of other actions like "build.compile".
+func (l *List) getReferenceProblems(actionName string) []Problem {
+	problems := []Problem{}
+	action := l.Actions[actionName]
+
+	for _, stepName := range action.GetStepNames() {
+		for i, dep := range action.Steps[stepName].Dependencies {
+			name, rest, ok := splitReference(dep)
+
+			if !ok {
+				continue
+			}
+
+			path := fmt.Sprintf("actions.%s.steps.%s.dependencies[%d]", actionName, stepName, i)
+
+			if name == actionName {
+				problems = append(problems, Problem{Path: path, Message: fmt.Sprintf("step %q of the same action has to be referenced as %q", dep, rest)})
+			} else if a, ok := l.Actions[name]; !ok {
+				problems = append(problems, Problem{Path: path, Message: fmt.Sprintf("unknown action %q", name)})
+			} else if _, ok := a.Steps[rest]; !ok {
+				problems = append(problems, Problem{Path: path, Message: fmt.Sprintf("unknown step %q", dep)})
+			}
+		}
+	}
+
+	return problems
+}
+
+// getStepGraphProblems returns the cycles of the graph of the steps of all
+// actions, the path of a problem is the path of a dependency causing it.
+func (l *List) getStepGraphProblems() []Problem {
+	problems := []Problem{}
+	seen := map[string]bool{}
+
+	for _, cycle := range l.GetStepGraph().GetCycles() {
+		edges := []string{}
+
+		for i := 0; i < len(cycle)-1; i++ {
+			path := l.getEdgePath(cycle[i], cycle[i+1])
+
+			if !slicex.Contains(edges, path) {
+				edges = append(edges, path)
+			}
+		}
+
+		sort.Strings(edges)
+
+		paths := strings.Join(edges, ", ")
+
+		if seen[paths] {
+			continue
+		}
+
+		seen[paths] = true
+
+		problems = append(
+			problems, Problem{
+				Path:    edges[0],
+				Message: fmt.Sprintf("dependency cycle %s, remove one of %s", strings.Join(cycle, " -> "), paths),
+			},
+		)
+	}
+
+	return problems
+}
+
+// getEdgePath returns the path of the configuration which makes a step like
+// "deploy.upload" depend on another step like "build.compile".
+func (l *List) getEdgePath(key string, dep string) string {
+	actionName, stepName, _ := splitReference(key)
+	depName, depStep, _ := splitReference(dep)
+	action := l.Actions[actionName]
+	step := action.Steps[stepName]
+
+	for i, d := range step.Dependencies {
+		if d == dep || (depName == actionName && d == depStep) {
+			return fmt.Sprintf("actions.%s.steps.%s.dependencies[%d]", actionName, stepName, i)
+		}
+	}
+
+	if slicex.Contains(l.getInputReferences(actionName, step.Input), dep) {
+		return fmt.Sprintf("actions.%s.steps.%s.input", actionName, stepName)
+	}
+
+	for i, d := range action.Dependencies {
+		if d == depName {
+			return fmt.Sprintf("actions.%s.dependencies[%d]", actionName, i)
+		}
+	}
+
+	return fmt.Sprintf("actions.%s.steps.%s", actionName, stepName)
+}
+
+// Validate checks the dependencies of the steps of the action, the path
+// like "actions.check" is the prefix of the paths of the problems.
+func (a *Action) Validate(path string) error {
+	return toValidationError(a.validate(path))
+}
+
+func (a *Action) validate(path string) []Problem {
+	return getGraphProblems(
+		a.GetGraph(), "step", func(key string) string {
+			return path + ".steps." + key + ".dependencies"
+		},
+	)
+}
+
+func toValidationError(problems []Problem) error {
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Problems: problems}
+}
+
+// getGraphProblems returns the problems of the graph, the path of a problem
+// is the path of the dependency causing it.
+func getGraphProblems(graph *topology.DependencyGraph, kind string, dependenciesPath func(key string) string) []Problem {
+	err := graph.Validate()
+
+	if err == nil {
+		return nil
+	}
+
+	var invalid *topology.ValidationError
+
+	if !errors.As(err, &invalid) {
+		return []Problem{{Path: dependenciesPath("*"), Message: err.Error()}}
+	}
+
+	path := func(dep topology.Dependency) string {
+		return fmt.Sprintf("%s[%d]", dependenciesPath(dep.Key), dep.Index)
+	}
+
+	problems := []Problem{}
+
+	for _, dep := range invalid.Dangling {
+		problems = append(problems, Problem{Path: path(dep), Message: fmt.Sprintf("unknown %s %q", kind, dep.Name)})
+	}
+
+	for _, dep := range invalid.SelfLoops {
+		problems = append(problems, Problem{Path: path(dep), Message: fmt.Sprintf("%s %q depends on itself", kind, dep.Key)})
+	}
+
+	for _, cycle := range invalid.Cycles {
+		edges := []string{}
+
+		for i := 0; i < len(cycle)-1; i++ {
+			if dep, ok := graph.GetDependency(cycle[i], cycle[i+1]); ok {
+				edges = append(edges, path(dep))
+			}
+		}
+
+		sort.Strings(edges)
+
+		problems = append(
+			problems, Problem{
+				Path:    edges[0],
+				Message: fmt.Sprintf("dependency cycle %s, remove one of %s", strings.Join(cycle, " -> "), strings.Join(edges, ", ")),
+			},
+		)
+	}
+
+	return problems
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestList_Validate(t *testing.T) {
+	list := &List{
+		Actions: map[string]*Action{
+			"check": {
+				Dependencies: []string{"missing"},
+				Steps: map[string]*Step{
+					"build": {Type: "print", Dependencies: []string{"lint"}},
+					"a":     {Type: "print", Dependencies: []string{"b"}},
+					"b":     {Type: "print", Dependencies: []string{"build", "a"}},
+				},
+			},
+		},
+	}
+
+	err := list.Validate()
+
+	var invalid *ValidationError
+
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+
+	want := []Problem{
+		{Path: "actions.check.dependencies[0]", Message: `unknown action "missing"`},
+		{Path: "actions.check.steps.build.dependencies[0]", Message: `unknown step "lint"`},
+		{Path: "actions.check.steps.a.dependencies[0]", Message: "dependency cycle a -> b -> a, remove one of actions.check.steps.a.dependencies[0], actions.check.steps.b.dependencies[1]"},
+	}
+
+	if !reflect.DeepEqual(invalid.Problems, want) {
+		t.Errorf("Validate() problems = %v, want %v", invalid.Problems, want)
+	}
+}
+
+func TestList_Validate_References(t *testing.T) {
+	list := &List{
+		Actions: map[string]*Action{
+			"build": {
+				Steps: map[string]*Step{
+					"compile": {Type: "print", Dependencies: []string{"deploy.upload"}},
+				},
+			},
+			"deploy": {
+				Steps: map[string]*Step{
+					"upload": {Type: "print", Input: "build.compile.output"},
+					"notify": {Type: "print", Dependencies: []string{"deploy.upload", "missing.x", "build.x"}},
+				},
+			},
+		},
+	}
+
+	err := list.Validate()
+
+	var invalid *ValidationError
+
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+
+	want := []Problem{
+		{Path: "actions.deploy.steps.notify.dependencies[0]", Message: `step "deploy.upload" of the same action has to be referenced as "upload"`},
+		{Path: "actions.deploy.steps.notify.dependencies[1]", Message: `unknown action "missing"`},
+		{Path: "actions.deploy.steps.notify.dependencies[2]", Message: `unknown step "build.x"`},
+	}
+
+	if !reflect.DeepEqual(invalid.Problems, want) {
+		t.Errorf("Validate() problems = %v, want %v", invalid.Problems, want)
+	}
+
+	list.Actions["deploy"].Steps["notify"].Dependencies = nil
+
+	err = list.Validate()
+
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+
+	want = []Problem{
+		{Path: "actions.build.steps.compile.dependencies[0]", Message: "dependency cycle build.compile -> deploy.upload -> build.compile, remove one of actions.build.steps.compile.dependencies[0], actions.deploy.steps.upload.input"},
+	}
+
+	if !reflect.DeepEqual(invalid.Problems, want) {
+		t.Errorf("Validate() problems = %v, want %v", invalid.Problems, want)
+	}
+}
@@ -0,0 +1,335 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/chapterjason/j3n/mod/topology"
+	"github.com/chapterjason/j3n/modx/filepathx"
+)
+
+const DefaultWatchDebounce = Duration(200 * time.Millisecond)
+
+var (
+	ErrNothingToWatch = errors.New("nothing to watch, declare inputs on the steps or watch paths on the action")
+
+	DefaultWatchIgnore = []string{".git/**", ".j3n/**"}
+)
+
+// Watch configures which files trigger a rerun of an action in watch mode, in
+// addition to the inputs of its steps.
+type Watch struct {
+	Paths    []string `json:"paths,omitempty" yaml:"paths,omitempty"`
+	Ignore   []string `json:"ignore,omitempty" yaml:"ignore,omitempty"`
+	Debounce Duration `json:"debounce,omitempty" yaml:"debounce,omitempty"`
+}
+
+// Watcher executes an action and reruns the affected steps whenever files
+// change.
+type Watcher struct {
+	executer   *Executer
+	actionName string
+	graph      *topology.DependencyGraph
+	// needed are the steps a normal run of the action executes, changes
+	// only rerun these.
+	needed   Selection
+	ignore   []string
+	debounce time.Duration
+}
+
+func NewWatcher(executer *Executer, actionName string) (*Watcher, error) {
+	if _, err := executer.list.GetAction(actionName); err != nil {
+		return nil, errors.Wrapf(err, "action %s", actionName)
+	}
+
+	graph := topology.NewDependencyGraph()
+	graph.Add(executer.list.GetGraph(), actionName)
+
+	w := &Watcher{
+		executer:   executer,
+		actionName: actionName,
+		graph:      graph,
+		needed:     executer.list.GetSelection(actionName),
+		ignore:     append([]string{}, DefaultWatchIgnore...),
+		debounce:   time.Duration(DefaultWatchDebounce),
+	}
+
+	watched := false
+
+	for _, name := range graph.GetKeys() {
+		action := executer.list.Actions[name]
+
+		if action.Watch != nil {
+			watched = watched || len(action.Watch.Paths) > 0
+			w.ignore = append(w.ignore, action.Watch.Ignore...)
+
+			if name == actionName && action.Watch.Debounce > 0 {
+				w.debounce = time.Duration(action.Watch.Debounce)
+			}
+		}
+
+		for _, step := range action.Steps {
+			watched = watched || len(step.Inputs) > 0
+
+			// changes to the outputs of a step must not trigger another run
+			w.ignore = append(w.ignore, step.Outputs...)
+		}
+	}
+
+	if !watched {
+		return nil, ErrNothingToWatch
+	}
+
+	return w, nil
+}
+
+// Run executes the action and then reruns the affected steps on every change
+// until the context is done. Every report is passed to the callback.
+func (w *Watcher) Run(ctx context.Context, callback func(*Report)) error {
+	fw, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return errors.Wrap(err, "failed to create file watcher")
+	}
+
+	defer fw.Close()
+
+	if err := w.add(fw, "."); err != nil {
+		return err
+	}
+
+	var cancel context.CancelFunc
+	var done chan struct{}
+	var running Selection
+
+	start := func(selection Selection) {
+		var runCtx context.Context
+
+		runCtx, cancel = context.WithCancel(ctx)
+		done = make(chan struct{})
+		running = selection
+
+		go func(done chan struct{}) {
+			defer close(done)
+
+			report, err := w.executer.ExecuteSelection(runCtx, w.actionName, selection)
+
+			if err != nil {
+				log.Errorf("%s", err)
+
+				return
+			}
+
+			callback(report)
+		}(done)
+	}
+
+	// a running execution is cancelled and waited for on every return
+	stop := func() {
+		cancel()
+		<-done
+	}
+
+	start(w.needed)
+
+	defer stop()
+
+	changed := map[string]bool{}
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-fw.Errors:
+			return errors.Wrap(err, "failed to watch files")
+		case event := <-fw.Events:
+			file := filepath.Clean(event.Name)
+
+			if event.Op == fsnotify.Chmod || w.isIgnored(file) {
+				continue
+			}
+
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(file); err == nil && info.IsDir() {
+					if err := w.add(fw, file); err != nil {
+						return err
+					}
+				}
+			}
+
+			changed[file] = true
+			timer.Reset(w.debounce)
+		case <-timer.C:
+			files := []string{}
+
+			for file := range changed {
+				files = append(files, file)
+			}
+
+			changed = map[string]bool{}
+
+			selection := w.affected(files)
+
+			if len(selection) == 0 {
+				continue
+			}
+
+			select {
+			case <-done:
+			default:
+				log.Infof("change detected, cancelling the running execution")
+
+				// the steps of the cancelled execution have to run again
+				selection = selection.Merge(running)
+			}
+
+			stop()
+
+			log.Infof("change detected, executing action %s", w.actionName)
+
+			start(selection)
+		}
+	}
+}
+
+// add watches the directory and all its subdirectories which are not
+// ignored.
+func (w *Watcher) add(fw *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(
+		root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() {
+				return nil
+			}
+
+			if p != "." && w.isIgnored(p) {
+				return filepath.SkipDir
+			}
+
+			if err := fw.Add(p); err != nil {
+				return errors.Wrapf(err, "failed to watch %s", p)
+			}
+
+			return nil
+		},
+	)
+}
+
+func (w *Watcher) isIgnored(file string) bool {
+	for _, pattern := range w.ignore {
+		if matches(pattern, file) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// affected returns the steps which have to run again because of the changed
+// files. These are the steps whose inputs changed, all steps of actions whose
+// watch paths changed, and everything depending on them, as far as a normal
+// run of the action executes them.
+func (w *Watcher) affected(files []string) Selection {
+	graph := w.executer.list.GetStepGraph()
+	selection := Selection{}
+
+	for _, actionName := range w.graph.GetKeys() {
+		action := w.executer.list.Actions[actionName]
+		watched := action.Watch != nil && matchesAny(action.Watch.Paths, files)
+
+		for stepName, step := range action.Steps {
+			if !watched && !matchesAny(step.Inputs, files) {
+				continue
+			}
+
+			for _, key := range dependents(graph, actionName+"."+stepName) {
+				name, dependent, _ := splitReference(key)
+
+				if w.needed.Includes(name, dependent) {
+					selection.Add(name, dependent)
+				}
+			}
+		}
+	}
+
+	return selection
+}
+
+// dependents returns the key and all keys which directly or indirectly depend
+// on it.
+func dependents(dg *topology.DependencyGraph, key string) []string {
+	visited := map[string]bool{key: true}
+	queue := []string{key}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, dependent := range dg.GetDependents(current) {
+			if !visited[dependent] {
+				visited[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	keys := []string{}
+
+	for k := range visited {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+func matchesAny(patterns []string, files []string) bool {
+	for _, pattern := range patterns {
+		for _, file := range files {
+			if matches(pattern, file) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func matches(pattern string, file string) bool {
+	ok, err := filepathx.Match(pattern, file)
+
+	return err == nil && ok
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package action
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWatcher_Affected(t *testing.T) {
+	list := &List{
+		Actions: map[string]*Action{
+			"build": {
+				Steps: map[string]*Step{
+					"generate": {Type: "test.outputs", Inputs: []string{"**/*.proto"}},
+					"compile":  {Type: "test.outputs", Dependencies: []string{"generate"}, Inputs: []string{"**/*.go"}},
+					"docs":     {Type: "test.outputs", Inputs: []string{"docs/**"}},
+				},
+			},
+			"lint": {
+				Watch: &Watch{Paths: []string{".golangci.yml"}},
+				Steps: map[string]*Step{
+					"vet":  {Type: "test.outputs"},
+					"lint": {Type: "test.outputs"},
+				},
+			},
+			"test": {
+				Dependencies: []string{"build", "lint"},
+				Steps: map[string]*Step{
+					"run": {Type: "test.outputs"},
+				},
+			},
+			"other": {
+				Steps: map[string]*Step{
+					"run": {Type: "test.outputs", Inputs: []string{"**/*.go"}},
+				},
+			},
+		},
+	}
+
+	w, err := NewWatcher(NewExecuter(list, Options{}), "test")
+
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+
+	tests := map[string]struct {
+		files []string
+		want  Selection
+	}{
+		"input": {
+			files: []string{"cmd/main.go"},
+			want:  Selection{"build": {"compile": true}, "test": {"run": true}},
+		},
+		"dependents": {
+			files: []string{"api/api.proto"},
+			want:  Selection{"build": {"generate": true, "compile": true}, "test": {"run": true}},
+		},
+		"watch paths": {
+			files: []string{".golangci.yml"},
+			want:  Selection{"lint": {"vet": true, "lint": true}, "test": {"run": true}},
+		},
+		"several": {
+			files: []string{"docs/index.md", ".golangci.yml"},
+			want:  Selection{"build": {"docs": true}, "lint": {"vet": true, "lint": true}, "test": {"run": true}},
+		},
+		"unrelated": {
+			files: []string{"README.md"},
+			want:  Selection{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := w.affected(tt.files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("affected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWatcher_NothingToWatch(t *testing.T) {
+	list := &List{Actions: map[string]*Action{"test": {Steps: map[string]*Step{"run": {Type: "test.outputs"}}}}}
+
+	if _, err := NewWatcher(NewExecuter(list, Options{}), "test"); err != ErrNothingToWatch {
+		t.Errorf("NewWatcher() error = %v, want %v", err, ErrNothingToWatch)
+	}
+}
+
+func TestWatcher_Needed(t *testing.T) {
+	list := &List{
+		Actions: map[string]*Action{
+			"build": {
+				Steps: map[string]*Step{
+					"compile": {Type: "test.outputs", Inputs: []string{"**/*.go"}},
+					"docs":    {Type: "test.outputs", Inputs: []string{"docs/**"}},
+				},
+			},
+			"release": {
+				Steps: map[string]*Step{
+					"publish": {Type: "test.outputs", Input: "build.compile.value"},
+				},
+			},
+		},
+	}
+
+	e := NewExecuter(list, Options{})
+	w, err := NewWatcher(e, "release")
+
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+
+	// the first run executes the same steps as a normal run
+	if want := list.GetSelection("release"); !reflect.DeepEqual(w.needed, want) || w.needed.Includes("build", "docs") {
+		t.Errorf("NewWatcher() needed = %v, want %v", w.needed, want)
+	}
+
+	if got := w.affected([]string{"docs/index.md"}); len(got) != 0 {
+		t.Errorf("affected() = %v, want nothing", got)
+	}
+
+	if got, want := w.affected([]string{"main.go"}), (Selection{"build": {"compile": true}, "release": {"publish": true}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("affected() = %v, want %v", got, want)
+	}
+}
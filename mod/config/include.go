@@ -0,0 +1,202 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gogs/git-module"
+	"github.com/pkg/errors"
+)
+
+// DefaultIncludeFile is the file which is loaded from a git ref, if the
+// include does not name one.
+const DefaultIncludeFile = "j3n.json"
+
+var (
+	ErrNestedInclude    = errors.New("included files must not include other files")
+	ErrInvalidInclude   = errors.New("include must be a path or an object with a path")
+	ErrIncludeNotFound  = errors.New("include not found")
+	ErrInvalidNamespace = errors.New("namespace must only contain word characters and dashes")
+
+	namespaceExpression = regexp.MustCompile(`^[\w-]+$`)
+	// invalidNamespaceExpression matches the characters of a file or
+	// repository name which are replaced in the namespace, like the "." of
+	// "go.v2".
+	invalidNamespaceExpression = regexp.MustCompile(`[^\w-]+`)
+)
+
+// Include loads the actions and templates of a file, of all json files of
+// a directory or of a file at a git ref of a vendored repository.
+type Include struct {
+	Path string `json:"path"`
+	// Ref is a git ref of the repository at Path, like "v1.2.0".
+	Ref string `json:"ref,omitempty"`
+	// File is the file which is loaded from the git ref.
+	File string `json:"file,omitempty"`
+	// Namespace is prepended to the names of the actions and templates, like
+	// "go" in "go:test". The name of the file or the repository is used if
+	// it is empty, with other characters than word characters and dashes
+	// replaced by dashes.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+func (i *Include) UnmarshalJSON(bytes []byte) error {
+	var path string
+
+	if err := json.Unmarshal(bytes, &path); err == nil {
+		i.Path = path
+
+		return nil
+	}
+
+	type include Include
+
+	var v include
+
+	if err := json.Unmarshal(bytes, &v); err != nil || v.Path == "" {
+		return ErrInvalidInclude
+	}
+
+	*i = Include(v)
+
+	return nil
+}
+
+// source is an included file.
+type source struct {
+	name      string
+	namespace string
+	config    map[string]any
+}
+
+// load returns the sources of the include, relative paths are relative to
+// the directory.
+func (i Include) load(directory string) ([]*source, error) {
+	path := i.Path
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(directory, path)
+	}
+
+	if i.Ref != "" {
+		return i.loadRef(path)
+	}
+
+	info, err := os.Stat(path)
+
+	if os.IsNotExist(err) {
+		return nil, errors.Wrap(ErrIncludeNotFound, i.Path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.json"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(files)
+	}
+
+	sources := []*source{}
+
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		name := filepath.Join(i.Path, strings.TrimPrefix(file, path))
+		s, err := i.parse(name, strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)), b)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, s)
+	}
+
+	return sources, nil
+}
+
+func (i Include) loadRef(path string) ([]*source, error) {
+	file := i.File
+
+	if file == "" {
+		file = DefaultIncludeFile
+	}
+
+	name := i.Path + "@" + i.Ref + ":" + file
+
+	b, err := git.NewCommand("show", i.Ref+":"+filepath.ToSlash(file)).RunInDirWithTimeout(time.Duration(0), path)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "include %s", name)
+	}
+
+	s, err := i.parse(name, filepath.Base(path), b)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []*source{s}, nil
+}
+
+func (i Include) parse(name string, namespace string, b []byte) (*source, error) {
+	config := map[string]any{}
+
+	if err := json.Unmarshal(b, &config); err != nil {
+		return nil, errors.Wrapf(err, "include %s", name)
+	}
+
+	if _, ok := config["include"]; ok {
+		return nil, errors.Wrapf(ErrNestedInclude, "include %s", name)
+	}
+
+	if i.Namespace != "" {
+		if !namespaceExpression.MatchString(i.Namespace) {
+			return nil, errors.Wrapf(ErrInvalidNamespace, "include %s", name)
+		}
+
+		namespace = i.Namespace
+	}
+
+	namespace = invalidNamespaceExpression.ReplaceAllString(namespace, "-")
+
+	return &source{name: name, namespace: namespace, config: config}, nil
+}
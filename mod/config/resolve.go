@@ -0,0 +1,331 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chapterjason/j3n/mod/action"
+	"github.com/chapterjason/j3n/mod/expression"
+	"github.com/chapterjason/j3n/modx/viperx"
+)
+
+// Separator separates the namespace of an included action or template from
+// its name, like in "go:test".
+const Separator = ":"
+
+// ConflictError lists the actions and templates which are defined more than
+// once.
+type ConflictError struct {
+	Conflicts []string
+}
+
+func (e *ConflictError) Error() string {
+	return "conflicting includes:\n  " + strings.Join(e.Conflicts, "\n  ")
+}
+
+// Resolve returns the settings with the actions and templates of the
+// includes merged into them. The names of included actions and templates and
+// the references between them are prefixed with the namespace of the
+// include. Relative paths are relative to the directory.
+func Resolve(settings map[string]any, directory string) (map[string]any, error) {
+	value, ok := settings["include"]
+
+	if !ok {
+		return settings, nil
+	}
+
+	var includes []Include
+
+	if err := viperx.Transcode(value, &includes); err != nil {
+		return nil, err
+	}
+
+	resolved := copyMap(settings)
+	origins := map[string]string{}
+	conflicts := []string{}
+
+	delete(resolved, "include")
+
+	for _, kind := range []string{"actions", "templates"} {
+		if _, ok := settings[kind]; !ok {
+			continue
+		}
+
+		resolved[kind] = copyMap(toMap(settings[kind]))
+
+		for name := range toMap(settings[kind]) {
+			origins[kind+"."+name] = "config"
+		}
+	}
+
+	for _, include := range includes {
+		sources, err := include.load(directory)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range sources {
+			for _, kind := range []string{"actions", "templates"} {
+				merged := toMap(resolved[kind])
+				entries := s.getNamespaced(kind)
+
+				for _, name := range sortedKeys(entries) {
+					if origin, ok := origins[kind+"."+name]; ok {
+						conflicts = append(conflicts, fmt.Sprintf("%s %q is defined in %s and %s", strings.TrimSuffix(kind, "s"), name, origin, s.name))
+
+						continue
+					}
+
+					origins[kind+"."+name] = s.name
+					merged[name] = entries[name]
+				}
+
+				if len(merged) > 0 {
+					resolved[kind] = merged
+				}
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, &ConflictError{Conflicts: conflicts}
+	}
+
+	return resolved, nil
+}
+
+// getNamespaced returns the actions or templates of the source with the
+// namespace prepended to their names and to the references, placeholders
+// and conditions referring to the actions and templates of the source.
+func (s *source) getNamespaced(kind string) map[string]any {
+	actions := toMap(s.config["actions"])
+	templates := toMap(s.config["templates"])
+
+	prefix := func(names map[string]any, name any) any {
+		if n, ok := name.(string); ok {
+			if _, ok := names[n]; ok {
+				return s.namespace + Separator + n
+			}
+		}
+
+		return name
+	}
+
+	// qualified references like "build.compile" are prefixed if they
+	// reference an action of the source.
+	qualified := func(reference any, steps map[string]any) any {
+		r, ok := reference.(string)
+
+		if !ok {
+			return reference
+		}
+
+		i := strings.Index(r, ".")
+
+		if i <= 0 {
+			return reference
+		}
+
+		if _, ok := steps[r[:i]]; ok {
+			return reference
+		}
+
+		if _, ok := actions[r[:i]]; ok {
+			return s.namespace + Separator + r
+		}
+
+		return reference
+	}
+
+	// placeholders like "{{build.compile.stdout}}" in strings, lists and
+	// objects are prefixed like qualified references.
+	var placeholders func(value any, steps map[string]any) any
+
+	placeholders = func(value any, steps map[string]any) any {
+		switch v := value.(type) {
+		case string:
+			return action.PlaceholderExpression.ReplaceAllStringFunc(
+				v, func(placeholder string) string {
+					name := action.PlaceholderExpression.FindStringSubmatch(placeholder)[1]
+
+					if name == "" {
+						return placeholder
+					}
+
+					return strings.Replace(placeholder, name, qualified(name, steps).(string), 1)
+				},
+			)
+		case []any:
+			prefixed := []any{}
+
+			for _, item := range v {
+				prefixed = append(prefixed, placeholders(item, steps))
+			}
+
+			return prefixed
+		case map[string]any:
+			prefixed := map[string]any{}
+
+			for key, item := range v {
+				prefixed[key] = placeholders(item, steps)
+			}
+
+			return prefixed
+		}
+
+		return value
+	}
+
+	// the identifiers of the "if" and "unless" conditions are prefixed like
+	// qualified references. Invalid conditions are kept and fail when they
+	// are evaluated.
+	conditions := func(config map[string]any, steps map[string]any) {
+		for _, key := range []string{"if", "unless"} {
+			condition, ok := config[key].(string)
+
+			if !ok {
+				continue
+			}
+
+			prefixed, err := expression.RenameIdentifiers(
+				condition, func(name string) string {
+					return qualified(name, steps).(string)
+				},
+			)
+
+			if err == nil {
+				config[key] = prefixed
+			}
+		}
+	}
+
+	step := func(config map[string]any, steps map[string]any) map[string]any {
+		config = copyMap(config)
+
+		if uses, ok := config["uses"]; ok {
+			config["uses"] = prefix(templates, uses)
+		}
+
+		if deps, ok := config["dependencies"].([]any); ok {
+			prefixed := []any{}
+
+			for _, dep := range deps {
+				prefixed = append(prefixed, qualified(dep, nil))
+			}
+
+			config["dependencies"] = prefixed
+		}
+
+		if params, ok := config["params"]; ok {
+			config["params"] = placeholders(params, steps)
+		}
+
+		conditions(config, steps)
+
+		return config
+	}
+
+	namespaced := map[string]any{}
+
+	for name, value := range toMap(s.config[kind]) {
+		config := toMap(value)
+
+		if kind == "templates" {
+			namespaced[s.namespace+Separator+name] = step(config, nil)
+
+			continue
+		}
+
+		config = copyMap(config)
+
+		if deps, ok := config["dependencies"].([]any); ok {
+			prefixed := []any{}
+
+			for _, dep := range deps {
+				prefixed = append(prefixed, prefix(actions, dep))
+			}
+
+			config["dependencies"] = prefixed
+		}
+
+		if extends, ok := config["extends"]; ok {
+			config["extends"] = prefix(actions, extends)
+		}
+
+		conditions(config, toMap(config["steps"]))
+
+		steps := map[string]any{}
+
+		for stepName, stepValue := range toMap(config["steps"]) {
+			stepConfig := step(toMap(stepValue), toMap(config["steps"]))
+
+			if input, ok := stepConfig["input"]; ok {
+				stepConfig["input"] = qualified(input, toMap(config["steps"]))
+			}
+
+			steps[stepName] = stepConfig
+		}
+
+		if _, ok := config["steps"]; ok {
+			config["steps"] = steps
+		}
+
+		namespaced[s.namespace+Separator+name] = config
+	}
+
+	return namespaced
+}
+
+func toMap(value any) map[string]any {
+	if m, ok := value.(map[string]any); ok {
+		return m
+	}
+
+	return map[string]any{}
+}
+
+func copyMap(m map[string]any) map[string]any {
+	copied := make(map[string]any, len(m))
+
+	for key, value := range m {
+		copied[key] = value
+	}
+
+	return copied
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := []string{}
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestResolve(t *testing.T) {
+	directory := t.TempDir()
+
+	files := map[string]string{
+		"ci/go.json": `{
+			"templates": {"go": {"type": "exec", "params": {"command": "go"}}},
+			"actions": {
+				"build": {"steps": {"compile": {"uses": "go"}}},
+				"test": {
+					"dependencies": ["build", "other"],
+					"if": "!empty(build.binary)",
+					"steps": {"run": {
+						"uses": "shared",
+						"dependencies": ["build.compile"],
+						"input": "build.compile.stdout",
+						"if": "build.compile.exit_code == 0 && contains(param.tags, 'build.x')",
+						"params": {"args": ["{{ build.compile.stdout }}", "{{param.tags}}", "{{{{build}}"]}
+					}}
+				}
+			}
+		}`,
+		"lint.json":     `{"actions": {"vet": {"steps": {"run": {"uses": "go"}}}}}`,
+		"tools.v2.json": `{"actions": {"fmt": {"steps": {"run": {"uses": "go"}}}}}`,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(directory, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	settings := map[string]any{
+		"include": []any{"ci", "tools.v2.json", map[string]any{"path": "lint.json", "namespace": "go"}},
+		"actions": map[string]any{"check": map[string]any{"dependencies": []any{"go:test"}}},
+	}
+
+	resolved, err := Resolve(settings, directory)
+
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	if _, ok := resolved["include"]; ok {
+		t.Errorf("Resolve() kept the includes")
+	}
+
+	actions := resolved["actions"].(map[string]any)
+
+	want := map[string]any{
+		"dependencies": []any{"go:build", "other"},
+		"if":           "!empty(go:build.binary)",
+		"steps": map[string]any{
+			"run": map[string]any{
+				"uses":         "shared",
+				"dependencies": []any{"go:build.compile"},
+				"input":        "go:build.compile.stdout",
+				"if":           "go:build.compile.exit_code == 0 && contains(param.tags, 'build.x')",
+				"params":       map[string]any{"args": []any{"{{ go:build.compile.stdout }}", "{{param.tags}}", "{{{{build}}"}},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(actions["go:test"], want) {
+		t.Errorf("Resolve() got action %v, want %v", actions["go:test"], want)
+	}
+
+	if got := actions["go:vet"].(map[string]any)["steps"].(map[string]any)["run"]; !reflect.DeepEqual(got, map[string]any{"uses": "go"}) {
+		t.Errorf("Resolve() got step %v, want a reference to the template of the configuration", got)
+	}
+
+	if _, ok := resolved["templates"].(map[string]any)["go:go"]; !ok {
+		t.Errorf("Resolve() got templates %v, want go:go", resolved["templates"])
+	}
+
+	if _, ok := actions["tools-v2:fmt"]; !ok {
+		t.Errorf("Resolve() got actions %v, want tools-v2:fmt", actions)
+	}
+
+	settings["include"] = []any{map[string]any{"path": "lint.json", "namespace": "go.v2"}}
+
+	if _, err := Resolve(settings, directory); !errors.Is(err, ErrInvalidNamespace) {
+		t.Errorf("Resolve() error = %v, want %v", err, ErrInvalidNamespace)
+	}
+
+	settings["include"] = []any{"ci", map[string]any{"path": "ci/go.json"}}
+	settings["actions"] = map[string]any{"go:test": map[string]any{}}
+
+	_, err = Resolve(settings, directory)
+
+	var conflict *ConflictError
+
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Resolve() error = %v, want *ConflictError", err)
+	}
+
+	conflicts := []string{
+		`action "go:test" is defined in config and ci/go.json`,
+		`action "go:build" is defined in ci/go.json and ci/go.json`,
+		`action "go:test" is defined in config and ci/go.json`,
+		`template "go:go" is defined in ci/go.json and ci/go.json`,
+	}
+
+	if !reflect.DeepEqual(conflict.Conflicts, conflicts) {
+		t.Errorf("Resolve() conflicts = %v, want %v", conflict.Conflicts, conflicts)
+	}
+}
@@ -0,0 +1,529 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+// Package expression implements the small expression language used by the
+// conditions of actions and steps, like
+//
+//	env.CI == "true" && git.branch =~ "^release/" && !(os == "windows")
+//
+// Identifiers are resolved by the caller, strings are quoted with single or
+// double quotes and the functions contains, starts_with, ends_with and empty
+// are available.
+package expression
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var ErrUnknownFunction = errors.New("unknown function")
+
+// Variables returns the value of an identifier.
+type Variables func(name string) (any, error)
+
+type Expression struct {
+	text string
+	root node
+}
+
+type node interface {
+	evaluate(variables Variables) (any, error)
+}
+
+func Parse(text string) (*Expression, error) {
+	tokens, err := tokenize(text)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %q", text)
+	}
+
+	p := &parser{tokens: tokens}
+
+	root, err := p.parseOr()
+
+	if err == nil && p.peek().kind != tokenEOF {
+		err = errors.Errorf("unexpected %q at position %d", p.peek().text, p.peek().position)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %q", text)
+	}
+
+	return &Expression{text: text, root: root}, nil
+}
+
+// Evaluate returns whether the expression is truthy.
+func (e *Expression) Evaluate(variables Variables) (bool, error) {
+	v, err := e.root.evaluate(variables)
+
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to evaluate %q", e.text)
+	}
+
+	return Truthy(v), nil
+}
+
+func (e *Expression) String() string {
+	return e.text
+}
+
+// Evaluate parses and evaluates the expression.
+func Evaluate(text string, variables Variables) (bool, error) {
+	e, err := Parse(text)
+
+	if err != nil {
+		return false, err
+	}
+
+	return e.Evaluate(variables)
+}
+
+// RenameIdentifiers returns the expression with the identifiers replaced
+// by the result of rename. Literals, strings and the names of functions are
+// kept.
+func RenameIdentifiers(text string, rename func(name string) string) (string, error) {
+	tokens, err := tokenize(text)
+
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse %q", text)
+	}
+
+	var b strings.Builder
+
+	position := 0
+
+	for i, t := range tokens {
+		if t.kind != tokenIdentifier || t.text == "true" || t.text == "false" || t.text == "null" || tokens[i+1].kind == tokenLeftParen {
+			continue
+		}
+
+		b.WriteString(text[position:t.position])
+		b.WriteString(rename(t.text))
+
+		position = t.position + len(t.text)
+	}
+
+	b.WriteString(text[position:])
+
+	return b.String(), nil
+}
+
+// Truthy returns false for nil, false, zero and blank strings and true for
+// everything else.
+func Truthy(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case float64:
+		return t != 0
+	case int:
+		return t != 0
+	case string:
+		return strings.TrimSpace(t) != ""
+	}
+
+	return true
+}
+
+type parser struct {
+	tokens []token
+	index  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.index]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.index]
+
+	if t.kind != tokenEOF {
+		p.index++
+	}
+
+	return t
+}
+
+func (p *parser) accept(kind tokenKind, text string) bool {
+	t := p.peek()
+
+	if t.kind == kind && (text == "" || t.text == text) {
+		p.index++
+
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept(tokenOperator, "||") {
+		right, err := p.parseAnd()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &logicalNode{operator: "||", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept(tokenOperator, "&&") {
+		right, err := p.parseNot()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &logicalNode{operator: "&&", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.accept(tokenOperator, "!") {
+		operand, err := p.parseNot()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &notNode{operand: operand}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+
+	if t.kind == tokenOperator && t.text != "&&" && t.text != "||" && t.text != "!" {
+		p.next()
+
+		right, err := p.parsePrimary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &comparisonNode{operator: t.text, left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenString, tokenNumber:
+		return &literalNode{value: t.value}, nil
+	case tokenLeftParen:
+		inner, err := p.parseOr()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.accept(tokenRightParen, "") {
+			return nil, errors.Errorf("expected \")\" at position %d", p.peek().position)
+		}
+
+		return inner, nil
+	case tokenIdentifier:
+		switch t.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+
+		if p.accept(tokenLeftParen, "") {
+			return p.parseCall(t)
+		}
+
+		return &identifierNode{name: t.text}, nil
+	case tokenEOF:
+		return nil, errors.New("unexpected end of expression")
+	}
+
+	return nil, errors.Errorf("unexpected %q at position %d", t.text, t.position)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	call := &callNode{name: name.text}
+
+	if p.accept(tokenRightParen, "") {
+		return call, nil
+	}
+
+	for {
+		argument, err := p.parseOr()
+
+		if err != nil {
+			return nil, err
+		}
+
+		call.arguments = append(call.arguments, argument)
+
+		if p.accept(tokenRightParen, "") {
+			return call, nil
+		}
+
+		if !p.accept(tokenComma, "") {
+			return nil, errors.Errorf("expected \",\" or \")\" at position %d", p.peek().position)
+		}
+	}
+}
+
+type literalNode struct {
+	value any
+}
+
+func (n *literalNode) evaluate(_ Variables) (any, error) {
+	return n.value, nil
+}
+
+type identifierNode struct {
+	name string
+}
+
+func (n *identifierNode) evaluate(variables Variables) (any, error) {
+	return variables(n.name)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) evaluate(variables Variables) (any, error) {
+	v, err := n.operand.evaluate(variables)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return !Truthy(v), nil
+}
+
+type logicalNode struct {
+	operator string
+	left     node
+	right    node
+}
+
+func (n *logicalNode) evaluate(variables Variables) (any, error) {
+	left, err := n.left.evaluate(variables)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if n.operator == "&&" && !Truthy(left) {
+		return false, nil
+	}
+
+	if n.operator == "||" && Truthy(left) {
+		return true, nil
+	}
+
+	right, err := n.right.evaluate(variables)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Truthy(right), nil
+}
+
+type comparisonNode struct {
+	operator string
+	left     node
+	right    node
+}
+
+func (n *comparisonNode) evaluate(variables Variables) (any, error) {
+	left, err := n.left.evaluate(variables)
+
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := n.right.evaluate(variables)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.operator {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "=~", "!~":
+		expr, err := regexp.Compile(toString(right))
+
+		if err != nil {
+			return nil, err
+		}
+
+		return expr.MatchString(toString(left)) == (n.operator == "=~"), nil
+	}
+
+	l, lok := toNumber(left)
+	r, rok := toNumber(right)
+
+	if !lok || !rok {
+		return nil, errors.Errorf("operator %s requires numbers, got %q and %q", n.operator, toString(left), toString(right))
+	}
+
+	switch n.operator {
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	}
+
+	return nil, errors.Errorf("unknown operator %s", n.operator)
+}
+
+type callNode struct {
+	name      string
+	arguments []node
+}
+
+func (n *callNode) evaluate(variables Variables) (any, error) {
+	arguments := []string{}
+
+	for _, argument := range n.arguments {
+		v, err := argument.evaluate(variables)
+
+		if err != nil {
+			return nil, err
+		}
+
+		arguments = append(arguments, toString(v))
+	}
+
+	expected := 2
+
+	if n.name == "empty" {
+		expected = 1
+	}
+
+	if len(arguments) != expected {
+		return nil, errors.Errorf("%s expects %d arguments, got %d", n.name, expected, len(arguments))
+	}
+
+	switch n.name {
+	case "contains":
+		return strings.Contains(arguments[0], arguments[1]), nil
+	case "starts_with":
+		return strings.HasPrefix(arguments[0], arguments[1]), nil
+	case "ends_with":
+		return strings.HasSuffix(arguments[0], arguments[1]), nil
+	case "empty":
+		return strings.TrimSpace(arguments[0]) == "", nil
+	}
+
+	return nil, errors.Wrap(ErrUnknownFunction, n.name)
+}
+
+func equal(left any, right any) bool {
+	if l, ok := toNumber(left); ok {
+		if r, ok := toNumber(right); ok {
+			return l == r
+		}
+	}
+
+	if l, ok := left.(bool); ok {
+		return l == Truthy(right)
+	}
+
+	if r, ok := right.(bool); ok {
+		return r == Truthy(left)
+	}
+
+	return strings.TrimSpace(toString(left)) == strings.TrimSpace(toString(right))
+}
+
+func toNumber(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+func toString(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(v)
+}
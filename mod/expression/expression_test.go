@@ -0,0 +1,128 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package expression
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestEvaluate(t *testing.T) {
+	variables := func(name string) (any, error) {
+		switch name {
+		case "os":
+			return "linux", nil
+		case "env.CI":
+			return "true", nil
+		case "env.EMPTY":
+			return "", nil
+		case "git.branch":
+			return "release/1.2", nil
+		case "fmt.stdout":
+			return "main.go\n", nil
+		case "test.exit_code":
+			return 2, nil
+		}
+
+		return nil, errors.Errorf("unknown identifier %s", name)
+	}
+
+	tests := []struct {
+		expression string
+		want       bool
+		wantErr    bool
+	}{
+		{expression: "true", want: true},
+		{expression: "false", want: false},
+		{expression: "os == \"linux\"", want: true},
+		{expression: "os != 'linux'", want: false},
+		{expression: "env.CI", want: true},
+		{expression: "env.CI == true", want: true},
+		{expression: "env.EMPTY", want: false},
+		{expression: "!env.EMPTY", want: true},
+		{expression: "git.branch =~ \"^release/\"", want: true},
+		{expression: "git.branch !~ \"^release/\"", want: false},
+		{expression: "fmt.stdout", want: true},
+		{expression: "fmt.stdout == 'main.go'", want: true},
+		{expression: "test.exit_code == 2", want: true},
+		{expression: "test.exit_code >= 1 && test.exit_code < 3", want: true},
+		{expression: "env.EMPTY || os == 'windows'", want: false},
+		{expression: "!(os == 'windows') && (env.CI || env.EMPTY)", want: true},
+		{expression: "contains(git.branch, '1.2')", want: true},
+		{expression: "starts_with(git.branch, 'main')", want: false},
+		{expression: "empty(env.EMPTY)", want: true},
+		{expression: "unknown", wantErr: true},
+		{expression: "os ==", wantErr: true},
+		{expression: "(os == 'linux'", wantErr: true},
+		{expression: "os == 'linux", wantErr: true},
+		{expression: "upper(os)", wantErr: true},
+		{expression: "os < 1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.expression, func(t *testing.T) {
+				got, err := Evaluate(tt.expression, variables)
+
+				if (err != nil) != tt.wantErr {
+					t.Errorf("Evaluate() error = %v, wantErr %v", err, tt.wantErr)
+					return
+				}
+
+				if got != tt.want {
+					t.Errorf("Evaluate() got = %v, want %v", got, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func TestRenameIdentifiers(t *testing.T) {
+	rename := func(name string) string {
+		if strings.HasPrefix(name, "build.") {
+			return "go:" + name
+		}
+
+		return name
+	}
+
+	tests := map[string]string{
+		"build.compile.stdout != ''":                "go:build.compile.stdout != ''",
+		"contains(build.stdout, 'build.x') && true": "contains(go:build.stdout, 'build.x') && true",
+		"!empty(fmt.stdout)":                        "!empty(fmt.stdout)",
+	}
+
+	for text, want := range tests {
+		got, err := RenameIdentifiers(text, rename)
+
+		if err != nil || got != want {
+			t.Errorf("RenameIdentifiers(%q) got = %q, %v, want %q", text, got, err, want)
+		}
+	}
+
+	if _, err := Evaluate("go:build.stdout == 'x'", func(name string) (any, error) { return "x", nil }); err != nil {
+		t.Errorf("Evaluate() with a namespaced identifier error = %v", err)
+	}
+}
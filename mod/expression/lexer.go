@@ -0,0 +1,145 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package expression
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdentifier
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+type token struct {
+	kind     tokenKind
+	text     string
+	value    any
+	position int
+}
+
+var operators = []string{"==", "!=", "=~", "!~", "&&", "||", "<=", ">=", "<", ">", "!"}
+
+func tokenize(text string) ([]token, error) {
+	tokens := []token{}
+	i := 0
+
+	for i < len(text) {
+		c := rune(text[i])
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, text: "(", position: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, text: ")", position: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", position: i})
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+
+			for end < len(text) && rune(text[end]) != c {
+				if text[end] == '\\' {
+					end++
+				}
+
+				end++
+			}
+
+			if end >= len(text) {
+				return nil, errors.Errorf("unterminated string at position %d", i)
+			}
+
+			raw := text[i+1 : end]
+			value := strings.NewReplacer("\\\\", "\\", "\\\"", "\"", "\\'", "'").Replace(raw)
+
+			tokens = append(tokens, token{kind: tokenString, text: text[i : end+1], value: value, position: i})
+			i = end + 1
+		case unicode.IsDigit(c):
+			end := i
+
+			for end < len(text) && (unicode.IsDigit(rune(text[end])) || text[end] == '.') {
+				end++
+			}
+
+			value, err := strconv.ParseFloat(text[i:end], 64)
+
+			if err != nil {
+				return nil, errors.Errorf("invalid number %q at position %d", text[i:end], i)
+			}
+
+			tokens = append(tokens, token{kind: tokenNumber, text: text[i:end], value: value, position: i})
+			i = end
+		case isIdentifierRune(c):
+			end := i
+
+			// identifiers may contain the namespace of included actions, like
+			// "go:build.compile.stdout".
+			for end < len(text) && (isIdentifierRune(rune(text[end])) || unicode.IsDigit(rune(text[end])) || strings.ContainsRune(".-:", rune(text[end]))) {
+				end++
+			}
+
+			tokens = append(tokens, token{kind: tokenIdentifier, text: text[i:end], position: i})
+			i = end
+		default:
+			operator := ""
+
+			for _, o := range operators {
+				if strings.HasPrefix(text[i:], o) {
+					operator = o
+
+					break
+				}
+			}
+
+			if operator == "" {
+				return nil, errors.Errorf("unexpected character %q at position %d", c, i)
+			}
+
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, position: i})
+			i += len(operator)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, position: len(text)}), nil
+}
+
+func isIdentifierRune(c rune) bool {
+	return unicode.IsLetter(c) || c == '_'
+}
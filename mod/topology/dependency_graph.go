@@ -23,7 +23,8 @@
 package topology
 
 import (
-	"github.com/chapterjason/j3n/modx/mapx"
+	"sort"
+
 	"github.com/chapterjason/j3n/modx/slicex"
 )
 
@@ -48,49 +49,67 @@ func (dg *DependencyGraph) AddEdge(from, to string) {
 }
 
 func (dg *DependencyGraph) IsCyclic() bool {
-	visited := make(map[string]bool)
-	recursionStack := make(map[string]bool)
-
-	for key := range dg.nodes {
-		visited[key] = false
-		recursionStack[key] = false
-	}
+	return len(dg.GetCycles()) > 0
+}
 
-	for key := range dg.nodes {
-		if !mapx.HasKey(visited, key) && dg.isCyclic(key, &visited, &recursionStack) {
-			return true
+// GetCycles returns the cycles of the graph as paths like [a b a], in which
+// every key depends on the next one. A cyclic graph returns at least one
+// cycle, not necessarily every cycle of it.
+func (dg *DependencyGraph) GetCycles() [][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	cycles := [][]string{}
+	state := map[string]int{}
+	path := []string{}
+
+	var visit func(key string)
+
+	visit = func(key string) {
+		state[key] = visiting
+		path = append(path, key)
+
+		deps := append([]string{}, dg.nodes[key]...)
+		sort.Strings(deps)
+
+		for _, dep := range deps {
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				start := slicex.IndexOf(path, dep)
+				cycles = append(cycles, append(append([]string{}, path[start:]...), dep))
+			}
 		}
-	}
 
-	return false
-}
+		path = path[:len(path)-1]
+		state[key] = visited
+	}
 
-func (dg *DependencyGraph) isCyclic(key string, visited *map[string]bool, recursionStack *map[string]bool) bool {
-	if !mapx.HasKey(*visited, key) {
-		(*visited)[key] = true
-		(*recursionStack)[key] = true
+	keys := dg.GetKeys()
+	sort.Strings(keys)
 
-		for _, dep := range dg.nodes[key] {
-			if !mapx.HasKey(*visited, dep) {
-				if dg.isCyclic(dep, visited, recursionStack) {
-					return true
-				}
-			} else if mapx.HasKey(*recursionStack, dep) {
-				return true
-			}
+	for _, key := range keys {
+		if state[key] == unvisited {
+			visit(key)
 		}
 	}
 
-	(*recursionStack)[key] = false
-
-	return false
+	return cycles
 }
 
 func (dg *DependencyGraph) Iterate() <-chan []string {
 	ch := make(chan []string)
 
 	go func() {
-		nodes := dg.nodes
+		nodes := make(map[string][]string, len(dg.nodes))
+
+		for key, deps := range dg.nodes {
+			nodes[key] = append([]string{}, deps...)
+		}
 
 		for {
 			var keys []string
@@ -122,6 +141,20 @@ func (dg *DependencyGraph) Iterate() <-chan []string {
 	return ch
 }
 
+// GetLayers returns the keys in the order of Iterate, every layer sorted.
+// Keys which are part of a cycle or depend on one are not returned.
+func (dg *DependencyGraph) GetLayers() [][]string {
+	layers := [][]string{}
+
+	for layer := range dg.Iterate() {
+		sort.Strings(layer)
+
+		layers = append(layers, layer)
+	}
+
+	return layers
+}
+
 func (dg *DependencyGraph) GetKeys() []string {
 	keys := []string{}
 
@@ -136,13 +169,30 @@ func (dg *DependencyGraph) GetDependencies(key string) []string {
 	return dg.nodes[key]
 }
 
+// GetDependents returns the keys which directly depend on the given key.
+func (dg *DependencyGraph) GetDependents(key string) []string {
+	dependents := []string{}
+
+	for k, deps := range dg.nodes {
+		if slicex.Contains(deps, key) {
+			dependents = append(dependents, k)
+		}
+	}
+
+	return dependents
+}
+
+// Add adds the key with all its direct and indirect dependencies of d.
 func (dg *DependencyGraph) Add(d *DependencyGraph, key string) {
+	if _, ok := dg.nodes[key]; ok {
+		return
+	}
+
 	deps := d.GetDependencies(key)
 
 	dg.AddNode(key)
 
 	for _, dep := range deps {
-		dg.AddNode(dep)
 		dg.AddEdge(key, dep)
 	}
 
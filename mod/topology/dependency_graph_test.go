@@ -0,0 +1,101 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package topology
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDependencyGraph_GetCycles(t *testing.T) {
+	dg := NewDependencyGraph()
+
+	dg.AddNode("a")
+	dg.AddEdge("b", "a")
+	dg.AddEdge("c", "b")
+	dg.AddEdge("d", "e")
+	dg.AddEdge("e", "f")
+	dg.AddEdge("f", "d")
+	dg.AddEdge("g", "g")
+
+	want := [][]string{{"d", "e", "f", "d"}, {"g", "g"}}
+
+	if got := dg.GetCycles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetCycles() = %v, want %v", got, want)
+	}
+
+	if !dg.IsCyclic() {
+		t.Errorf("IsCyclic() = false, want true")
+	}
+
+	if got := dg.GetLayers(); !reflect.DeepEqual(got, [][]string{{"a"}, {"b"}, {"c"}}) {
+		t.Errorf("GetLayers() = %v", got)
+	}
+}
+
+func TestDependencyGraph_IsCyclic(t *testing.T) {
+	dg := NewDependencyGraph()
+
+	dg.AddEdge("b", "a")
+	dg.AddEdge("c", "a")
+	dg.AddEdge("c", "b")
+
+	if dg.IsCyclic() {
+		t.Errorf("IsCyclic() = true, want false")
+	}
+}
+
+func TestDependencyGraph_Validate(t *testing.T) {
+	dg := NewDependencyGraph()
+
+	dg.AddNode("a")
+	dg.AddEdge("a", "a")
+	dg.AddEdge("b", "c")
+	dg.AddEdge("b", "missing")
+	dg.AddEdge("c", "b")
+
+	err := dg.Validate()
+
+	var invalid *ValidationError
+
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Validate() error = %v, want *ValidationError", err)
+	}
+
+	if want := [][]string{{"b", "c", "b"}}; !reflect.DeepEqual(invalid.Cycles, want) {
+		t.Errorf("Validate() cycles = %v, want %v", invalid.Cycles, want)
+	}
+
+	if want := []Dependency{{Key: "a", Index: 0, Name: "a"}}; !reflect.DeepEqual(invalid.SelfLoops, want) {
+		t.Errorf("Validate() self loops = %v, want %v", invalid.SelfLoops, want)
+	}
+
+	if want := []Dependency{{Key: "b", Index: 1, Name: "missing"}}; !reflect.DeepEqual(invalid.Dangling, want) {
+		t.Errorf("Validate() dangling = %v, want %v", invalid.Dangling, want)
+	}
+
+	if err := NewDependencyGraph().Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package topology
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Dependency is the dependency at the index of the dependencies of a key.
+type Dependency struct {
+	Key   string
+	Index int
+	Name  string
+}
+
+// ValidationError lists the problems of a dependency graph.
+type ValidationError struct {
+	// Cycles are paths like [a b c a] in which every key depends on the next.
+	Cycles [][]string
+	// SelfLoops are the dependencies of keys on themselves.
+	SelfLoops []Dependency
+	// Dangling are the dependencies on keys which are not in the graph.
+	Dangling []Dependency
+}
+
+func (e *ValidationError) Error() string {
+	problems := []string{}
+
+	for _, cycle := range e.Cycles {
+		problems = append(problems, "cycle "+strings.Join(cycle, " -> "))
+	}
+
+	for _, dep := range e.SelfLoops {
+		problems = append(problems, fmt.Sprintf("%s depends on itself", dep.Key))
+	}
+
+	for _, dep := range e.Dangling {
+		problems = append(problems, fmt.Sprintf("%s depends on unknown %s", dep.Key, dep.Name))
+	}
+
+	return strings.Join(problems, ", ")
+}
+
+// GetDependency returns the dependency of the key on the other key, which
+// is used to find the edges of a cycle.
+func (dg *DependencyGraph) GetDependency(key string, dependency string) (Dependency, bool) {
+	for i, dep := range dg.nodes[key] {
+		if dep == dependency {
+			return Dependency{Key: key, Index: i, Name: dep}, true
+		}
+	}
+
+	return Dependency{}, false
+}
+
+// Validate returns a *ValidationError if the graph has cycles, keys which
+// depend on themselves or dependencies on keys which are not in the graph.
+func (dg *DependencyGraph) Validate() error {
+	result := &ValidationError{
+		Cycles:    [][]string{},
+		SelfLoops: []Dependency{},
+		Dangling:  []Dependency{},
+	}
+
+	keys := dg.GetKeys()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for i, dep := range dg.nodes[key] {
+			if dep == key {
+				result.SelfLoops = append(result.SelfLoops, Dependency{Key: key, Index: i, Name: dep})
+			} else if _, ok := dg.nodes[dep]; !ok {
+				result.Dangling = append(result.Dangling, Dependency{Key: key, Index: i, Name: dep})
+			}
+		}
+	}
+
+	for _, cycle := range dg.GetCycles() {
+		if len(cycle) > 2 {
+			result.Cycles = append(result.Cycles, cycle)
+		}
+	}
+
+	if len(result.Cycles) == 0 && len(result.SelfLoops) == 0 && len(result.Dangling) == 0 {
+		return nil
+	}
+
+	return result
+}
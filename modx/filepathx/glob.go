@@ -0,0 +1,156 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package filepathx
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Glob works like filepath.Glob, but additionally supports "**" path segments,
+// which match any number of directories.
+func Glob(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		return filepath.Glob(pattern)
+	}
+
+	root := base(pattern)
+	matches := []string{}
+
+	err := filepath.WalkDir(
+		root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && p == root {
+					return filepath.SkipDir
+				}
+
+				return err
+			}
+
+			ok, err := Match(pattern, p)
+
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				matches = append(matches, p)
+			}
+
+			return nil
+		},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(matches)
+
+	return matches, nil
+}
+
+// Match works like filepath.Match, but additionally supports "**" path
+// segments, which match any number of directories.
+func Match(pattern, name string) (bool, error) {
+	return matchSegments(split(pattern), split(name))
+}
+
+func matchSegments(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				ok, err := matchSegments(pattern[1:], name[i:])
+
+				if err != nil || ok {
+					return ok, err
+				}
+			}
+
+			return false, nil
+		}
+
+		if len(name) == 0 {
+			return false, nil
+		}
+
+		ok, err := filepath.Match(pattern[0], name[0])
+
+		if err != nil || !ok {
+			return false, err
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0, nil
+}
+
+// base returns the longest leading directory of the pattern without any
+// meta characters.
+func base(pattern string) string {
+	segments := split(pattern)
+	static := []string{}
+
+	for _, segment := range segments {
+		if strings.ContainsAny(segment, "*?[\\") {
+			break
+		}
+
+		static = append(static, segment)
+	}
+
+	if len(static) == 0 {
+		if filepath.IsAbs(pattern) {
+			return string(filepath.Separator)
+		}
+
+		return "."
+	}
+
+	root := filepath.Join(static...)
+
+	if filepath.IsAbs(pattern) {
+		root = string(filepath.Separator) + root
+	}
+
+	return root
+}
+
+func split(p string) []string {
+	p = filepath.Clean(filepath.ToSlash(p))
+
+	segments := []string{}
+
+	for _, segment := range strings.Split(p, "/") {
+		if segment != "" && segment != "." {
+			segments = append(segments, segment)
+		}
+	}
+
+	return segments
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright © 2022 Jason Schilling
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in
+ * all copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+ * THE SOFTWARE.
+ */
+
+package filepathx
+
+import (
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "*.go", name: "main.go", want: true},
+		{pattern: "*.go", name: "cmd/root.go", want: false},
+		{pattern: "**/*.go", name: "main.go", want: true},
+		{pattern: "**/*.go", name: "cmd/root.go", want: true},
+		{pattern: "mod/**/*.go", name: "mod/action/step.go", want: true},
+		{pattern: "mod/**/*.go", name: "mod/a/b/c.go", want: true},
+		{pattern: "mod/**/*.go", name: "modx/slicex/map.go", want: false},
+		{pattern: "mod/**", name: "mod/action/step.go", want: true},
+		{pattern: "./mod/**", name: "mod/action", want: true},
+		{pattern: "dist/**", name: "mod/action", want: false},
+		{pattern: "**/.git/**", name: ".git/config", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.pattern+" "+tt.name, func(t *testing.T) {
+				got, err := Match(tt.pattern, tt.name)
+
+				if err != nil {
+					t.Errorf("Match() error = %v", err)
+					return
+				}
+
+				if got != tt.want {
+					t.Errorf("Match() got = %v, want %v", got, tt.want)
+				}
+			},
+		)
+	}
+}